@@ -10,6 +10,10 @@ import (
 	"io"
 	"log/slog"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 
@@ -17,16 +21,21 @@ import (
 
 	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
 )
 
 func main() {
-	if err := run(os.Stdout, os.Args[1:]); err != nil {
+	// Cancel generation (in-flight queries, pagination, uploads) on Ctrl+C / SIGTERM.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := run(ctx, os.Stdout, os.Args[1:])
+	stop()
+	if err != nil {
 		slog.Error("Generation failed", "error", err)
 		os.Exit(1)
 	}
 }
 
-func run(output io.Writer, args []string) error {
+func run(ctx context.Context, output io.Writer, args []string) error {
 	flags := flag.NewFlagSet("fibr-gen", flag.ContinueOnError)
 	flags.SetOutput(output)
 
@@ -47,6 +56,11 @@ func run(output io.Writer, args []string) error {
 		csvDir         string
 		s3Bucket       string
 		s3Prefix       string
+		recordDir      string
+		replayDir      string
+		cacheDir       string
+		cacheTTL       time.Duration
+		params         = paramFlag{}
 	)
 
 	// Register flags with both long and short names where appropriate
@@ -61,13 +75,22 @@ func run(output io.Writer, args []string) error {
 	flags.StringVar(&outputDir, "output", "./test/output", "Directory for output files")
 	flags.StringVar(&outputDir, "o", "./test/output", "Directory for output files (short)")
 
-	flags.StringVar(&fetcherType, "fetcher", "csv", "Data fetcher type: csv, dynamodb, mysql, postgres")
+	flags.StringVar(&fetcherType, "fetcher", "csv", "Data fetcher type: csv, datasource (route each view to its configured data source), dynamodb, mysql, postgres, sqlite")
 	flags.StringVar(&fetcherType, "f", "csv", "Data fetcher type (short)")
 
-	flags.StringVar(&dbDSN, "db-dsn", "", "Database connection string (DSN) for mysql/postgres")
+	flags.StringVar(&dbDSN, "db-dsn", "", "Database connection string (DSN) for mysql/postgres, or database file for sqlite")
 
 	flags.StringVar(&csvDir, "csv-dir", "./test/data_csv", "Directory containing CSV files for csv fetcher")
 
+	flags.Var(params, "param", "Workbook parameter as name=value, overriding the config (repeatable)")
+	flags.Var(params, "p", "Workbook parameter as name=value (short)")
+
+	flags.StringVar(&recordDir, "record", "", "Directory to record every fetched view into, for later replay")
+	flags.StringVar(&replayDir, "replay", "", "Directory of recorded views to serve instead of querying data sources")
+
+	flags.StringVar(&cacheDir, "cache-dir", "", "Directory of a fetch cache shared across runs (optional)")
+	flags.DurationVar(&cacheTTL, "cache-ttl", time.Hour, "Maximum age of fetch cache entries (0 = never expire)")
+
 	flags.StringVar(&s3Bucket, "s3-bucket", "", "S3 bucket name for uploading output")
 	flags.StringVar(&s3Prefix, "s3-prefix", "fibr-gen-output", "S3 prefix (folder) for uploaded files")
 
@@ -77,6 +100,9 @@ func run(output io.Writer, args []string) error {
 		}
 		return err
 	}
+	if recordDir != "" && replayDir != "" {
+		return fmt.Errorf("record and replay cannot be used together")
+	}
 
 	// Initialize structured logger
 	logger := slog.New(slog.NewTextHandler(output, &slog.HandlerOptions{
@@ -101,19 +127,47 @@ func run(output io.Writer, args []string) error {
 		slog.Info("Loaded data sources", "count", len(dataSources))
 	}
 
+	// Create Config Registry
+	configRegistry := config.NewMemoryConfigRegistry(views, dataSources)
+
 	// 2. Prepare Data Fetcher
-	var fetcher core.DataFetcher
+	var (
+		fetcher  core.DataFetcher
+		replayer *core.ReplayFetcher
+		recorder *core.RecordingFetcher
+	)
 
+	if replayDir != "" {
+		fetcherType = "replay"
+	}
 	switch fetcherType {
+	case "replay":
+		slog.Info("Replaying recorded data", "dir", replayDir)
+		replayer = core.NewReplayFetcher(replayDir)
+		fetcher = replayer
+	case "datasource":
+		if len(dataSources) == 0 {
+			return fmt.Errorf("datasource fetcher requires data sources in the config bundle or -datasources")
+		}
+		slog.Info("Initializing Routing Data Fetcher")
+		router := core.NewRoutingDataFetcher(configRegistry)
+		defer func() {
+			if err := router.Close(); err != nil {
+				slog.Warn("Failed to close data sources", "error", err)
+			}
+		}()
+		fetcher = router
 	case "dynamodb":
 		slog.Info("Initializing DynamoDB Data Fetcher")
 		// Load AWS Config (handles env vars, IAM roles, etc.)
-		cfg, err := awsconfig.LoadDefaultConfig(context.TODO())
+		cfg, err := awsconfig.LoadDefaultConfig(ctx)
 		if err != nil {
 			return fmt.Errorf("unable to load AWS SDK config: %w", err)
 		}
-		fetcher = core.NewDynamoDBDataFetcher(cfg)
-	case "mysql", "postgres":
+		dynamoFetcher := core.NewDynamoDBDataFetcher(cfg)
+		dynamoFetcher.Provider = configRegistry
+		fetcher = dynamoFetcher
+	case "mysql", "postgres", "sqlite":
 		if dbDSN == "" {
 			return fmt.Errorf("db-dsn is required for %s fetcher", fetcherType)
 		}
@@ -122,31 +176,57 @@ func run(output io.Writer, args []string) error {
 		if err != nil {
 			return fmt.Errorf("failed to open db connection: %w", err)
 		}
+		defer db.Close()
 		// Verify connection
-		if err := db.Ping(); err != nil {
+		if err := db.PingContext(ctx); err != nil {
 			return fmt.Errorf("failed to ping db: %w", err)
 		}
-		fetcher = core.NewSQLDataFetcher(db, fetcherType)
+		sqlFetcher := core.NewSQLDataFetcher(db, fetcherType)
+		sqlFetcher.Provider = configRegistry
+		fetcher = sqlFetcher
 	default:
 		// Default to CSV
 		slog.Info("Initializing CSV Data Fetcher", "dir", csvDir)
-		fetcher = core.NewCsvDataFetcher(csvDir)
+		csvFetcher := core.NewCsvDataFetcher(csvDir)
+		csvFetcher.Provider = configRegistry
+		fetcher = csvFetcher
+	}
+	if recordDir != "" {
+		slog.Info("Recording fetched data", "dir", recordDir)
+		recorder = core.NewRecordingFetcher(fetcher, recordDir)
+		fetcher = recorder
 	}
 
 	// 3. Process Workbook
 	slog.Info("Processing Workbook", "name", wbConf.Name, "id", wbConf.Id)
 
-	// Create Config Registry
-	configRegistry := config.NewMemoryConfigRegistry(views, dataSources)
-
 	// Create Context
 	// Pass Registry instead of raw map
-	ctx := core.NewGenerationContext(wbConf, configRegistry, fetcher, map[string]string{
-		"env": "dev",
-	})
+	genCtx := core.NewGenerationContext(wbConf, configRegistry, fetcher, params)
 
-	generator := core.NewGenerator(ctx)
-	if err := generator.Generate(templateDir, outputDir); err != nil {
+	// Replay with the parameters resolved when recording (e.g. archive_date)
+	if replayer != nil {
+		params, err := replayer.Params()
+		if err != nil {
+			return fmt.Errorf("failed to read recorded params: %w", err)
+		}
+		for k, v := range params {
+			genCtx.Parameters[k] = v
+		}
+	}
+	if recorder != nil {
+		if err := recorder.RecordParams(genCtx.Parameters); err != nil {
+			return fmt.Errorf("failed to record params: %w", err)
+		}
+	}
+
+	if cacheDir != "" {
+		slog.Info("Using fetch cache", "dir", cacheDir, "ttl", cacheTTL)
+		genCtx.Cache.Disk = &core.DiskCache{Dir: cacheDir, TTL: cacheTTL, Configs: configRegistry}
+	}
+
+	generator := core.NewGenerator(genCtx)
+	if err := generator.GenerateContext(ctx, templateDir, outputDir); err != nil {
 		return fmt.Errorf("generate workbook %s: %w", wbConf.Name, err)
 	}
 
@@ -158,13 +238,13 @@ func run(output io.Writer, args []string) error {
 
 		// Load AWS Config if not already loaded (e.g. if fetcher was CSV)
 		// It's cheap to load again or we could have shared it.
-		cfg, err := awsconfig.LoadDefaultConfig(context.TODO())
+		cfg, err := awsconfig.LoadDefaultConfig(ctx)
 		if err != nil {
 			return fmt.Errorf("unable to load AWS SDK config for S3: %w", err)
 		}
 
 		uploader := core.NewS3Uploader(cfg, s3Bucket, s3Prefix)
-		if err := uploader.UploadDirectory(outputDir); err != nil {
+		if err := uploader.UploadDirectoryContext(ctx, outputDir); err != nil {
 			return fmt.Errorf("failed to upload output to s3: %w", err)
 		}
 		slog.Info("Successfully uploaded to S3")
@@ -172,3 +252,19 @@ func run(output io.Writer, args []string) error {
 
 	return nil
 }
+
+// paramFlag collects repeated name=value flags.
+type paramFlag map[string]string
+
+func (p paramFlag) String() string {
+	return ""
+}
+
+func (p paramFlag) Set(value string) error {
+	name, val, ok := strings.Cut(value, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("parameter %q is not name=value", value)
+	}
+	p[name] = val
+	return nil
+}
@@ -2,8 +2,11 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/xuri/excelize/v2"
@@ -47,7 +50,7 @@ configContent := `workbook:
 	}
 
 	var logs bytes.Buffer
-	if err := run(&logs, []string{
+	if err := run(context.Background(), &logs, []string{
 		"-config", configPath,
 		"-datasources", dataSourcePath,
 		"-templates", templateDir,
@@ -61,3 +64,50 @@ configContent := `workbook:
 		t.Fatalf("expected output file, got error: %v", err)
 	}
 }
+
+func TestRun_FlagErrors(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	configContent := `workbook:
+  id: "wb1"
+  name: "Report"
+  template: "template.xlsx"
+  outputDir: "out"
+  sheets:
+    - name: "Sheet1"
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"Datasource Without Sources", []string{"-config", configPath, "-fetcher", "datasource"}, "requires data sources"},
+		{"Malformed Param", []string{"-config", configPath, "-param", "month"}, "not name=value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			err := run(context.Background(), &logs, tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("run error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParamFlag(t *testing.T) {
+	params := paramFlag{}
+	for _, value := range []string{"env=prod", "filter=a=b", "empty="} {
+		if err := params.Set(value); err != nil {
+			t.Fatalf("Set(%q) error: %v", value, err)
+		}
+	}
+	want := paramFlag{"env": "prod", "filter": "a=b", "empty": ""}
+	if !reflect.DeepEqual(params, want) {
+		t.Fatalf("params = %v, want %v", params, want)
+	}
+}
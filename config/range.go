@@ -0,0 +1,111 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Sheet limits of the xlsx format.
+const (
+	maxColumns = 16384   // XFD
+	maxRows    = 1048576 // 2^20
+)
+
+// RangeBounds are the 1-based first and last column and row of a cell range.
+type RangeBounds struct {
+	StartCol, StartRow, EndCol, EndRow int
+}
+
+// Bounds parses the A1-style range, e.g. "A1:G33". A single cell such as
+// "B3" is a one-cell range, and reversed corners ("C3:A1") are normalized.
+func (r CellRange) Bounds() (RangeBounds, error) {
+	start, end, found := strings.Cut(r.Ref, ":")
+	if !found {
+		end = start
+	}
+	c1, r1, err := parseCellRef(start)
+	if err != nil {
+		return RangeBounds{}, fmt.Errorf("invalid range '%s': %w", r.Ref, err)
+	}
+	c2, r2, err := parseCellRef(end)
+	if err != nil {
+		return RangeBounds{}, fmt.Errorf("invalid range '%s': %w", r.Ref, err)
+	}
+	return RangeBounds{StartCol: min(c1, c2), StartRow: min(r1, r2), EndCol: max(c1, c2), EndRow: max(r1, r2)}, nil
+}
+
+// parseCellRef parses a cell reference such as "B3" into its column and row.
+func parseCellRef(ref string) (int, int, error) {
+	letters := 0
+	for letters < len(ref) && isLetter(ref[letters]) {
+		letters++
+	}
+	if letters == 0 || letters == len(ref) {
+		return 0, 0, fmt.Errorf("'%s' is not a cell reference", ref)
+	}
+	col := 0
+	for _, c := range strings.ToUpper(ref[:letters]) {
+		col = col*26 + int(c-'A') + 1
+		if col > maxColumns {
+			return 0, 0, fmt.Errorf("column of '%s' is out of bounds", ref)
+		}
+	}
+	digits := ref[letters:]
+	if digits[0] < '1' || digits[0] > '9' {
+		return 0, 0, fmt.Errorf("'%s' is not a cell reference", ref)
+	}
+	row, err := strconv.Atoi(digits)
+	if err != nil {
+		return 0, 0, fmt.Errorf("'%s' is not a cell reference", ref)
+	}
+	if row > maxRows {
+		return 0, 0, fmt.Errorf("row of '%s' is out of bounds", ref)
+	}
+	return col, row, nil
+}
+
+func isLetter(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z'
+}
+
+// Contains reports whether o lies within b.
+func (b RangeBounds) Contains(o RangeBounds) bool {
+	return b.StartCol <= o.StartCol && o.EndCol <= b.EndCol && b.StartRow <= o.StartRow && o.EndRow <= b.EndRow
+}
+
+// Overlaps reports whether b and o share cells.
+func (b RangeBounds) Overlaps(o RangeBounds) bool {
+	return b.StartCol <= o.EndCol && o.StartCol <= b.EndCol && b.StartRow <= o.EndRow && o.StartRow <= b.EndRow
+}
+
+// Intersect returns the cells shared by overlapping b and o.
+func (b RangeBounds) Intersect(o RangeBounds) RangeBounds {
+	return RangeBounds{
+		StartCol: max(b.StartCol, o.StartCol), StartRow: max(b.StartRow, o.StartRow),
+		EndCol: min(b.EndCol, o.EndCol), EndRow: min(b.EndRow, o.EndRow),
+	}
+}
+
+// Union returns the smallest range covering b and o.
+func (b RangeBounds) Union(o RangeBounds) RangeBounds {
+	return RangeBounds{
+		StartCol: min(b.StartCol, o.StartCol), StartRow: min(b.StartRow, o.StartRow),
+		EndCol: max(b.EndCol, o.EndCol), EndRow: max(b.EndRow, o.EndRow),
+	}
+}
+
+// String formats the bounds as an A1-style range, e.g. "A1:G33".
+func (b RangeBounds) String() string {
+	return columnName(b.StartCol) + strconv.Itoa(b.StartRow) + ":" + columnName(b.EndCol) + strconv.Itoa(b.EndRow)
+}
+
+func columnName(col int) string {
+	var name []byte
+	for col > 0 {
+		col--
+		name = append([]byte{byte('A' + col%26)}, name...)
+		col /= 26
+	}
+	return string(name)
+}
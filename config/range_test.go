@@ -0,0 +1,58 @@
+package config
+
+import "testing"
+
+func TestCellRange_Bounds(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    RangeBounds
+		wantErr bool
+	}{
+		{"A1:G33", RangeBounds{1, 1, 7, 33}, false},
+		{"B3", RangeBounds{2, 3, 2, 3}, false},
+		{"c3:a1", RangeBounds{1, 1, 3, 3}, false},
+		{"AA10:XFD1048576", RangeBounds{27, 10, 16384, 1048576}, false},
+		{"", RangeBounds{}, true},
+		{"A0", RangeBounds{}, true},
+		{"A01", RangeBounds{}, true},
+		{"11:12", RangeBounds{}, true},
+		{"A1:B", RangeBounds{}, true},
+		{"A1:B2:C3", RangeBounds{}, true},
+		{"$A$1", RangeBounds{}, true},
+		{"XFE1", RangeBounds{}, true},
+		{"A1048577", RangeBounds{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := CellRange{Ref: tt.ref}.Bounds()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Bounds(%q) error = %v, wantErr %v", tt.ref, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Bounds(%q) = %+v, want %+v", tt.ref, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRangeBounds_Relations(t *testing.T) {
+	a := RangeBounds{StartCol: 1, StartRow: 1, EndCol: 3, EndRow: 3} // A1:C3
+	b := RangeBounds{StartCol: 2, StartRow: 3, EndCol: 4, EndRow: 4} // B3:D4
+	c := RangeBounds{StartCol: 5, StartRow: 1, EndCol: 5, EndRow: 9} // E1:E9
+
+	if !a.Overlaps(b) || a.Overlaps(c) {
+		t.Error("Overlaps: want A1:C3 to overlap B3:D4 but not E1:E9")
+	}
+	if got := a.Intersect(b).String(); got != "B3:C3" {
+		t.Errorf("Intersect = %s, want B3:C3", got)
+	}
+	if got := a.Union(c).String(); got != "A1:E9" {
+		t.Errorf("Union = %s, want A1:E9", got)
+	}
+	if !a.Contains(RangeBounds{2, 2, 3, 3}) || a.Contains(b) {
+		t.Error("Contains: want A1:C3 to contain B2:C3 but not B3:D4")
+	}
+	if got := (RangeBounds{27, 1, 703, 2}).String(); got != "AA1:AAA2" {
+		t.Errorf("String = %s, want AA1:AAA2", got)
+	}
+}
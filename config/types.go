@@ -15,29 +15,193 @@ const (
 	DirectionHorizontal Direction = "horizontal"
 )
 
+type LabelType string
+
+const (
+	LabelTypeString   LabelType = "string"
+	LabelTypeInt      LabelType = "int"
+	LabelTypeDecimal  LabelType = "decimal"
+	LabelTypeDate     LabelType = "date"
+	LabelTypeDateTime LabelType = "datetime"
+	LabelTypeBool     LabelType = "bool"
+)
+
+// FilterOp is the comparison of a FilterConfig.
+type FilterOp string
+
+const (
+	FilterOpEq       FilterOp = "eq"       // equal
+	FilterOpNe       FilterOp = "ne"       // not equal
+	FilterOpLt       FilterOp = "lt"       // less than
+	FilterOpLe       FilterOp = "le"       // less than or equal
+	FilterOpGt       FilterOp = "gt"       // greater than
+	FilterOpGe       FilterOp = "ge"       // greater than or equal
+	FilterOpIn       FilterOp = "in"       // one of a comma-separated list
+	FilterOpPrefix   FilterOp = "prefix"   // text starts with
+	FilterOpContains FilterOp = "contains" // text contains
+	FilterOpNull     FilterOp = "null"     // missing or null, takes no value
+	FilterOpNotNull  FilterOp = "notNull"  // present and not null, takes no value
+)
+
+// FilterConfig restricts rows to those whose label value satisfies Op Value,
+// e.g. {label: date, op: ge, value: "${start}"}. Values are compared as the
+// label's Type (or as the fetched value's type) and may reference ${param};
+// a filter referencing an unset parameter is skipped.
+type FilterConfig struct {
+	Label string   `json:"label" yaml:"label"`
+	Op    FilterOp `json:"op" yaml:"op"`
+	Value string   `json:"value,omitempty" yaml:"value,omitempty"`
+}
+
+type SortDirection string
+
+const (
+	SortAsc  SortDirection = "asc"
+	SortDesc SortDirection = "desc"
+)
+
+// SortConfig is a sort key. Values compare as Type, defaulting to the label's
+// type, or else numerically / chronologically when they all read as numbers /
+// dates, and as text otherwise. Values listed in Order come first, in that
+// order (e.g. month names); nulls always come last.
+type SortConfig struct {
+	Label     string        `json:"label" yaml:"label"`
+	Direction SortDirection `json:"direction,omitempty" yaml:"direction,omitempty"` // asc (default) / desc
+	Type      LabelType     `json:"type,omitempty" yaml:"type,omitempty"`
+	Order     []string      `json:"order,omitempty" yaml:"order,omitempty"`
+}
+
 type CellRange struct {
 	Ref string `json:"ref" yaml:"ref"` // e.g. "A1:G33"
 }
 
 type DataSourceConfig struct {
 	Name   string `json:"name"   yaml:"name"`
-	Driver string `json:"driver" yaml:"driver"` // "mysql", "sqlserver", "postgres"
-	DSN    string `json:"dsn"    yaml:"dsn"`    // 连接串
+	Driver string `json:"driver" yaml:"driver"` // "mysql", "postgres", "sqlite", "csv", "json", "excel", "http", "dynamodb"
+	DSN    string `json:"dsn"    yaml:"dsn"`    // 连接串 (csv / json / excel: root directory, http: base URL)
+
+	// Driver specific
+	Csv  *CsvOptions        `json:"csv,omitempty" yaml:"csv,omitempty"` // defaults for the source's views
+	Http *HttpSourceOptions `json:"http,omitempty" yaml:"http,omitempty"`
+
+	Fetch *FetchPolicy `json:"fetch,omitempty" yaml:"fetch,omitempty"` // retries / timeout / logging around the source's fetches
+}
+
+// FetchPolicy configures the middleware wrapped around a data source's fetcher.
+type FetchPolicy struct {
+	Retries      int    `json:"retries,omitempty" yaml:"retries,omitempty"`           // retries of transient failures
+	RetryBackoff string `json:"retryBackoff,omitempty" yaml:"retryBackoff,omitempty"` // first retry delay, doubled per retry (default 200ms)
+	MaxBackoff   string `json:"maxBackoff,omitempty" yaml:"maxBackoff,omitempty"`     // cap on the retry delay (default 10s)
+	Timeout      string `json:"timeout,omitempty" yaml:"timeout,omitempty"`           // per view fetch attempt, e.g. "2m"
+	Log          bool   `json:"log,omitempty" yaml:"log,omitempty"`                   // log view, params, rows and duration of each fetch
+}
+
+// HttpSourceOptions configures requests to a REST data source whose DSN is the base URL.
+type HttpSourceOptions struct {
+	// Headers are templates that may reference ${param} and ${env:NAME},
+	// e.g. Authorization: "Bearer ${env:API_TOKEN}".
+	Headers      map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Pagination   *HttpPagination   `json:"pagination,omitempty" yaml:"pagination,omitempty"`
+	Timeout      string            `json:"timeout,omitempty" yaml:"timeout,omitempty"`           // per request, e.g. "30s" (default 30s)
+	Retries      int               `json:"retries,omitempty" yaml:"retries,omitempty"`           // retries of failed or 429 / 5xx requests (not with Fetch.Retries, as they would multiply)
+	RetryBackoff string            `json:"retryBackoff,omitempty" yaml:"retryBackoff,omitempty"` // first retry delay, doubled per retry (default 500ms)
+}
+
+// PaginationStyle defines how a REST endpoint pages its results.
+type PaginationStyle string
+
+const (
+	PaginationPage   PaginationStyle = "page"   // page number query param
+	PaginationOffset PaginationStyle = "offset" // row offset query param
+	PaginationCursor PaginationStyle = "cursor" // cursor read from the response body
+	PaginationLink   PaginationStyle = "link"   // rel="next" URL of the Link header
+)
+
+type HttpPagination struct {
+	Style       PaginationStyle `json:"style" yaml:"style"`
+	PageParam   string          `json:"pageParam,omitempty" yaml:"pageParam,omitempty"`     // page / offset query param (default "page" / "offset")
+	SizeParam   string          `json:"sizeParam,omitempty" yaml:"sizeParam,omitempty"`     // page size query param (default "limit")
+	PageSize    int             `json:"pageSize,omitempty" yaml:"pageSize,omitempty"`       // sent as SizeParam when set; a shorter page ends page / offset paging
+	CursorParam string          `json:"cursorParam,omitempty" yaml:"cursorParam,omitempty"` // cursor query param (default "cursor")
+	CursorPath  string          `json:"cursorPath,omitempty" yaml:"cursorPath,omitempty"`   // dotted path of the next cursor in the response
+	MaxPages    int             `json:"maxPages,omitempty" yaml:"maxPages,omitempty"`       // safety limit (default 1000)
+}
+
+// HttpViewOptions maps a view to a REST endpoint of its data source.
+type HttpViewOptions struct {
+	Path  string            `json:"path,omitempty" yaml:"path,omitempty"`   // path template relative to the base URL, may reference ${param} (default: Table)
+	Query map[string]string `json:"query,omitempty" yaml:"query,omitempty"` // query param templates
+	Root  string            `json:"root,omitempty" yaml:"root,omitempty"`   // dotted path to the row array in the response
+}
+
+// CsvOptions describes the dialect and location of CSV files.
+// Options set on a data view override those of its data source.
+type CsvOptions struct {
+	Delimiter string   `json:"delimiter,omitempty" yaml:"delimiter,omitempty"` // single character (default ",")
+	Quote     string   `json:"quote,omitempty" yaml:"quote,omitempty"`         // single character (default `"`)
+	Comment   string   `json:"comment,omitempty" yaml:"comment,omitempty"`     // lines starting with it are skipped
+	Encoding  string   `json:"encoding,omitempty" yaml:"encoding,omitempty"`   // e.g. "gbk", "shift_jis" (default UTF-8)
+	Header    *bool    `json:"header,omitempty" yaml:"header,omitempty"`       // first row holds column names (default: true unless Columns is set)
+	Columns   []string `json:"columns,omitempty" yaml:"columns,omitempty"`     // explicit column names, replacing the header row
+	Path      string   `json:"path,omitempty" yaml:"path,omitempty"`           // file path or glob relative to the DSN, may reference ${param}
+}
+
+// JsonOptions describes the layout of JSON files.
+type JsonOptions struct {
+	Format string `json:"format,omitempty" yaml:"format,omitempty"` // "json" or "ndjson" (default: by file extension)
+	Root   string `json:"root,omitempty" yaml:"root,omitempty"`     // dotted path to the rows inside the document, e.g. "data.items"
+	Path   string `json:"path,omitempty" yaml:"path,omitempty"`     // file path or glob relative to the DSN, may reference ${param}
+}
+
+// ExcelOptions locates the rows of a view inside a workbook. Rows come from the
+// Excel table or named range if set, otherwise from Sheet below HeaderRow.
+type ExcelOptions struct {
+	Sheet     string `json:"sheet,omitempty" yaml:"sheet,omitempty"`         // default: first sheet
+	HeaderRow int    `json:"headerRow,omitempty" yaml:"headerRow,omitempty"` // 1-based (default 1)
+	Range     string `json:"range,omitempty" yaml:"range,omitempty"`         // defined name or A1 range on Sheet, header in its first row
+	Table     string `json:"table,omitempty" yaml:"table,omitempty"`         // Excel table name
+	Path      string `json:"path,omitempty" yaml:"path,omitempty"`           // file path or glob relative to the DSN, may reference ${param}
 }
 
 type LabelConfig struct {
-	Name   string `json:"name"   yaml:"name"`   // label name
-	Column string `json:"column" yaml:"column"` // actual column name in db
-	Type   string `json:"type,omitempty" yaml:"type,omitempty"`
+	Name   string    `json:"name"   yaml:"name"`                   // label name
+	Column string    `json:"column" yaml:"column"`                 // actual column name in db
+	Type   LabelType `json:"type,omitempty" yaml:"type,omitempty"` // string / int / decimal / date / datetime / bool
+}
+
+// DynamoDBViewConfig declares the key schema a DynamoDB view can be queried by.
+type DynamoDBViewConfig struct {
+	PartitionKey string `json:"partitionKey,omitempty" yaml:"partitionKey,omitempty"` // attribute name
+	SortKey      string `json:"sortKey,omitempty" yaml:"sortKey,omitempty"`           // attribute name
+	IndexName    string `json:"indexName,omitempty" yaml:"indexName,omitempty"`       // optional GSI / LSI owning the keys
+
+	// AttributeTypes declares DynamoDB types of filtered attributes: S, N, BOOL,
+	// or SS / NS / L to filter on set or list membership. Defaults follow label types.
+	AttributeTypes map[string]string `json:"attributeTypes,omitempty" yaml:"attributeTypes,omitempty"`
+
+	// Parallel Scan: TotalSegments > 1 splits a Scan into segments read by up to
+	// ScanConcurrency workers (default 4).
+	TotalSegments   int `json:"totalSegments,omitempty" yaml:"totalSegments,omitempty"`
+	ScanConcurrency int `json:"scanConcurrency,omitempty" yaml:"scanConcurrency,omitempty"`
 }
 
 type DataViewConfig struct {
 	Id         string        `json:"id"         yaml:"id"`
 	Name       string        `json:"name"       yaml:"name"`
 	DataSource string        `json:"dataSource" yaml:"dataSource"`
-	Sql        string        `json:"sql,omitempty" yaml:"sql,omitempty"`
-	Table      string        `json:"table,omitempty" yaml:"table,omitempty"`
+	Sql        string        `json:"sql,omitempty" yaml:"sql,omitempty"`     // SELECT with :name parameter references
+	Table      string        `json:"table,omitempty" yaml:"table,omitempty"` // physical table / file / DynamoDB table (default: Name)
 	Labels     []LabelConfig `json:"labels" yaml:"labels"`
+
+	// Filters apply to every use of the view; SQL and DynamoDB push them down.
+	Filters []FilterConfig `json:"filters,omitempty" yaml:"filters,omitempty"`
+
+	// Driver specific
+	DynamoDB *DynamoDBViewConfig `json:"dynamodb,omitempty" yaml:"dynamodb,omitempty"`
+	Csv      *CsvOptions         `json:"csv,omitempty" yaml:"csv,omitempty"`
+	Json     *JsonOptions        `json:"json,omitempty" yaml:"json,omitempty"`
+	Excel    *ExcelOptions       `json:"excel,omitempty" yaml:"excel,omitempty"`
+	Http     *HttpViewOptions    `json:"http,omitempty" yaml:"http,omitempty"`
 }
 
 type BlockConfig struct {
@@ -53,6 +217,11 @@ type BlockConfig struct {
 	InsertAfter   bool      `json:"insertAfter,omitempty" yaml:"insertAfter,omitempty"`
 	LabelVariable string    `json:"labelVariable,omitempty" yaml:"labelVariable,omitempty"`
 
+	// Filters restrict the block's rows, after the data view's own filters.
+	Filters []FilterConfig `json:"filters,omitempty" yaml:"filters,omitempty"`
+	// Sort orders the block's rows (and so the values of header axes).
+	Sort []SortConfig `json:"sort,omitempty" yaml:"sort,omitempty"`
+
 	// Template ValueBlock of MatrixBlock
 	Template bool `json:"template,omitempty" yaml:"template,omitempty"`
 
@@ -68,6 +237,9 @@ type SheetConfig struct {
 	VerticalArrangement bool          `json:"verticalArrangement" yaml:"verticalArrangement"`
 	AllowOverlap        bool          `json:"allowOverlap" yaml:"allowOverlap"`
 	Blocks              []BlockConfig `json:"blocks"       yaml:"blocks"`
+
+	// Sort orders the sheets generated by a dynamic sheet (by its view's labels).
+	Sort []SortConfig `json:"sort,omitempty" yaml:"sort,omitempty"`
 }
 
 type WorkbookConfig struct {
@@ -2,6 +2,8 @@ package config
 
 import (
 	"fmt"
+	"time"
+	"unicode/utf8"
 )
 
 // Validator validates the configuration objects.
@@ -31,7 +33,7 @@ func (v *Validator) ValidateWorkbook(wb *WorkbookConfig) error {
 
 	for i := range wb.Sheets {
 		if err := v.ValidateSheet(&wb.Sheets[i]); err != nil {
-			return fmt.Errorf("sheet %d error: %w", i, err)
+			return fmt.Errorf("sheet %d ('%s') error: %w", i, wb.Sheets[i].Name, err)
 		}
 	}
 	return nil
@@ -50,11 +52,19 @@ func (v *Validator) ValidateSheet(sheet *SheetConfig) error {
 			return fmt.Errorf("dynamic sheet '%s' requires a ParamLabel", sheet.Name)
 		}
 		// Verify DataView exists
+		var view *DataViewConfig
 		if v.Provider != nil {
-			if _, err := v.Provider.GetDataViewConfig(sheet.DataViewName); err != nil {
+			dv, err := v.Provider.GetDataViewConfig(sheet.DataViewName)
+			if err != nil {
 				return fmt.Errorf("sheet '%s' references unknown DataView '%s'", sheet.Name, sheet.DataViewName)
 			}
+			view = dv
 		}
+		if err := validateSort(sheet.Sort, view); err != nil {
+			return fmt.Errorf("sheet '%s' %w", sheet.Name, err)
+		}
+	} else if len(sheet.Sort) > 0 {
+		return fmt.Errorf("sheet '%s' sort requires a dynamic sheet", sheet.Name)
 	}
 
 	for i := range sheet.Blocks {
@@ -62,6 +72,32 @@ func (v *Validator) ValidateSheet(sheet *SheetConfig) error {
 			return fmt.Errorf("block %d error: %w", i, err)
 		}
 	}
+	if !sheet.AllowOverlap {
+		if err := validateNoOverlap(sheet.Blocks); err != nil {
+			return fmt.Errorf("sheet '%s' %w", sheet.Name, err)
+		}
+	}
+	return nil
+}
+
+// validateNoOverlap checks that sibling blocks, and the sub-blocks of each
+// block, do not share cells. Ranges are assumed to be valid.
+func validateNoOverlap(blocks []BlockConfig) error {
+	bounds := make([]RangeBounds, len(blocks))
+	for i := range blocks {
+		bounds[i], _ = blocks[i].Range.Bounds()
+		for j := range i {
+			if bounds[i].Overlaps(bounds[j]) {
+				return fmt.Errorf("block '%s' (%s) overlaps block '%s' (%s) at %s",
+					blocks[i].Name, bounds[i], blocks[j].Name, bounds[j], bounds[i].Intersect(bounds[j]))
+			}
+		}
+	}
+	for i := range blocks {
+		if err := validateNoOverlap(blocks[i].SubBlocks); err != nil {
+			return fmt.Errorf("in block '%s': %w", blocks[i].Name, err)
+		}
+	}
 	return nil
 }
 
@@ -84,35 +120,46 @@ func (v *Validator) ValidateBlock(block *BlockConfig) error {
 	if block.Range.Ref == "" {
 		return fmt.Errorf("block '%s' range is required", block.Name)
 	}
+	bounds, err := block.Range.Bounds()
+	if err != nil {
+		return fmt.Errorf("block '%s' has %w", block.Name, err)
+	}
 
+	var view *DataViewConfig
 	if block.DataViewName != "" && v.Provider != nil {
-		if _, err := v.Provider.GetDataViewConfig(block.DataViewName); err != nil {
+		dv, err := v.Provider.GetDataViewConfig(block.DataViewName)
+		if err != nil {
 			return fmt.Errorf("block '%s' references unknown DataView '%s'", block.Name, block.DataViewName)
 		}
+		view = dv
+	}
+	if len(block.Filters) > 0 && block.DataViewName == "" {
+		return fmt.Errorf("block '%s' filters require a DataView", block.Name)
+	}
+	if err := validateFilters(block.Filters, view); err != nil {
+		return fmt.Errorf("block '%s' %w", block.Name, err)
+	}
+	if len(block.Sort) > 0 && block.DataViewName == "" {
+		return fmt.Errorf("block '%s' sort requires a DataView", block.Name)
+	}
+	if err := validateSort(block.Sort, view); err != nil {
+		return fmt.Errorf("block '%s' %w", block.Name, err)
 	}
 
 	if block.Type == BlockTypeMatrix {
 		if len(block.SubBlocks) == 0 {
 			return fmt.Errorf("matrix block '%s' must have sub-blocks", block.Name)
 		}
-		hasVertical := false
-		hasHorizontal := false
 		for i := range block.SubBlocks {
-			sb := &block.SubBlocks[i]
-			if err := v.ValidateBlock(sb); err != nil {
+			if err := v.ValidateBlock(&block.SubBlocks[i]); err != nil {
 				return fmt.Errorf("matrix block '%s' sub-block %d error: %w", block.Name, i, err)
 			}
-			if sb.Type == BlockTypeHeader {
-				switch sb.Direction {
-				case DirectionVertical, "":
-					hasVertical = true
-				case DirectionHorizontal:
-					hasHorizontal = true
-				}
-			}
 		}
-		if !hasVertical || !hasHorizontal {
-			return fmt.Errorf("matrix block '%s' must have both vertical and horizontal header blocks", block.Name)
+		if _, _, err := MatrixHeaders(block); err != nil {
+			return err
+		}
+		if err := validateMatrixLayout(block, bounds); err != nil {
+			return fmt.Errorf("matrix block '%s' %w", block.Name, err)
 		}
 	} else {
 		// Non-matrix blocks can also have sub-blocks?
@@ -128,6 +175,78 @@ func (v *Validator) ValidateBlock(block *BlockConfig) error {
 	return nil
 }
 
+// validateMatrixLayout checks that the sub-blocks of a matrix lie within it
+// and that its template blocks sit at the crossing of the header axes: within
+// the rows of the vertical header and the columns of the horizontal header,
+// which are repeated for every header value. The matrix must have exactly one
+// header of each direction.
+// MatrixHeaders returns the vertical and horizontal header blocks of a matrix
+// block. Each axis is given by exactly one header with an explicit direction.
+func MatrixHeaders(block *BlockConfig) (vertical, horizontal *BlockConfig, err error) {
+	for i := range block.SubBlocks {
+		sb := &block.SubBlocks[i]
+		if sb.Type != BlockTypeHeader {
+			continue
+		}
+		switch sb.Direction {
+		case DirectionVertical:
+			if vertical != nil {
+				return nil, nil, fmt.Errorf("matrix block '%s' has more than one vertical header block", block.Name)
+			}
+			vertical = sb
+		case DirectionHorizontal:
+			if horizontal != nil {
+				return nil, nil, fmt.Errorf("matrix block '%s' has more than one horizontal header block", block.Name)
+			}
+			horizontal = sb
+		default:
+			return nil, nil, fmt.Errorf("matrix block '%s' header block '%s' must set direction '%s' or '%s'",
+				block.Name, sb.Name, DirectionVertical, DirectionHorizontal)
+		}
+	}
+	if vertical == nil || horizontal == nil {
+		return nil, nil, fmt.Errorf("matrix block '%s' must have both vertical and horizontal header blocks", block.Name)
+	}
+	return vertical, horizontal, nil
+}
+
+func validateMatrixLayout(block *BlockConfig, bounds RangeBounds) error {
+	var vertical, horizontal *BlockConfig
+	var vBounds, hBounds RangeBounds
+	for i := range block.SubBlocks {
+		sb := &block.SubBlocks[i]
+		sbBounds, _ := sb.Range.Bounds()
+		if !bounds.Contains(sbBounds) {
+			return fmt.Errorf("sub-block '%s' (%s) lies outside the matrix range %s", sb.Name, sbBounds, bounds)
+		}
+		if sb.Type == BlockTypeHeader {
+			switch sb.Direction {
+			case DirectionVertical:
+				vertical, vBounds = sb, sbBounds
+			case DirectionHorizontal:
+				horizontal, hBounds = sb, sbBounds
+			}
+		}
+	}
+
+	for i := range block.SubBlocks {
+		sb := &block.SubBlocks[i]
+		if sb.Type == BlockTypeHeader && !sb.Template {
+			continue
+		}
+		sbBounds, _ := sb.Range.Bounds()
+		if sbBounds.StartRow < vBounds.StartRow || sbBounds.EndRow > vBounds.EndRow {
+			return fmt.Errorf("template block '%s' (%s) is not aligned with the rows of vertical header '%s' (%s)",
+				sb.Name, sbBounds, vertical.Name, vBounds)
+		}
+		if sbBounds.StartCol < hBounds.StartCol || sbBounds.EndCol > hBounds.EndCol {
+			return fmt.Errorf("template block '%s' (%s) is not aligned with the columns of horizontal header '%s' (%s)",
+				sb.Name, sbBounds, horizontal.Name, hBounds)
+		}
+	}
+	return nil
+}
+
 // ValidateDataView validates the DataViewConfig.
 func (v *Validator) ValidateDataView(dv *DataViewConfig) error {
 	if dv.Name == "" {
@@ -141,6 +260,44 @@ func (v *Validator) ValidateDataView(dv *DataViewConfig) error {
 			return fmt.Errorf("data view '%s' references unknown DataSource '%s'", dv.Name, dv.DataSource)
 		}
 	}
+	if dv.DynamoDB != nil && dv.DynamoDB.PartitionKey == "" && (dv.DynamoDB.SortKey != "" || dv.DynamoDB.IndexName != "") {
+		return fmt.Errorf("data view '%s' DynamoDB key schema requires a partitionKey", dv.Name)
+	}
+	if dv.DynamoDB != nil {
+		if dv.DynamoDB.TotalSegments < 0 || dv.DynamoDB.TotalSegments > 1000000 {
+			return fmt.Errorf("data view '%s' totalSegments must be between 0 and 1000000", dv.Name)
+		}
+		if dv.DynamoDB.ScanConcurrency < 0 {
+			return fmt.Errorf("data view '%s' scanConcurrency must not be negative", dv.Name)
+		}
+		for attr, typ := range dv.DynamoDB.AttributeTypes {
+			switch typ {
+			case "S", "N", "BOOL", "SS", "NS", "L":
+				// OK
+			default:
+				return fmt.Errorf("data view '%s' attribute '%s' has invalid DynamoDB type '%s'", dv.Name, attr, typ)
+			}
+		}
+	}
+	if err := validateCsvOptions(dv.Csv); err != nil {
+		return fmt.Errorf("data view '%s' %w", dv.Name, err)
+	}
+	if dv.Json != nil {
+		switch dv.Json.Format {
+		case "", "json", "ndjson":
+			// OK
+		default:
+			return fmt.Errorf("data view '%s' has invalid json format '%s'", dv.Name, dv.Json.Format)
+		}
+	}
+	if dv.Excel != nil {
+		if dv.Excel.HeaderRow < 0 {
+			return fmt.Errorf("data view '%s' excel headerRow must not be negative", dv.Name)
+		}
+		if dv.Excel.Range != "" && dv.Excel.Table != "" {
+			return fmt.Errorf("data view '%s' excel range and table are mutually exclusive", dv.Name)
+		}
+	}
 	for i, label := range dv.Labels {
 		if label.Name == "" {
 			return fmt.Errorf("data view '%s' label %d name is required", dv.Name, i)
@@ -148,10 +305,77 @@ func (v *Validator) ValidateDataView(dv *DataViewConfig) error {
 		if label.Column == "" {
 			return fmt.Errorf("data view '%s' label %d column is required", dv.Name, i)
 		}
+		switch label.Type {
+		case "", LabelTypeString, LabelTypeInt, LabelTypeDecimal, LabelTypeDate, LabelTypeDateTime, LabelTypeBool:
+			// OK
+		default:
+			return fmt.Errorf("data view '%s' label '%s' has invalid type '%s'", dv.Name, label.Name, label.Type)
+		}
+	}
+	if err := validateFilters(dv.Filters, dv); err != nil {
+		return fmt.Errorf("data view '%s' %w", dv.Name, err)
+	}
+	return nil
+}
+
+// validateFilters checks filter operators and values, and, when the view is
+// known, that filters name its labels.
+func validateFilters(filters []FilterConfig, view *DataViewConfig) error {
+	for i, filter := range filters {
+		if filter.Label == "" {
+			return fmt.Errorf("filter %d label is required", i)
+		}
+		switch filter.Op {
+		case FilterOpNull, FilterOpNotNull:
+			if filter.Value != "" {
+				return fmt.Errorf("filter on '%s' with op '%s' takes no value", filter.Label, filter.Op)
+			}
+		case FilterOpEq, FilterOpNe, FilterOpLt, FilterOpLe, FilterOpGt, FilterOpGe, FilterOpIn, FilterOpPrefix, FilterOpContains:
+			// OK
+		default:
+			return fmt.Errorf("filter on '%s' has invalid op '%s'", filter.Label, filter.Op)
+		}
+		if view != nil && !hasLabel(view, filter.Label) {
+			return fmt.Errorf("filter references unknown label '%s' of data view '%s'", filter.Label, view.Name)
+		}
+	}
+	return nil
+}
+
+// validateSort checks sort keys and, when the view is known, that they name its labels.
+func validateSort(keys []SortConfig, view *DataViewConfig) error {
+	for i, key := range keys {
+		if key.Label == "" {
+			return fmt.Errorf("sort key %d label is required", i)
+		}
+		switch key.Direction {
+		case "", SortAsc, SortDesc:
+			// OK
+		default:
+			return fmt.Errorf("sort key '%s' has invalid direction '%s'", key.Label, key.Direction)
+		}
+		switch key.Type {
+		case "", LabelTypeString, LabelTypeInt, LabelTypeDecimal, LabelTypeDate, LabelTypeDateTime, LabelTypeBool:
+			// OK
+		default:
+			return fmt.Errorf("sort key '%s' has invalid type '%s'", key.Label, key.Type)
+		}
+		if view != nil && !hasLabel(view, key.Label) {
+			return fmt.Errorf("sort key references unknown label '%s' of data view '%s'", key.Label, view.Name)
+		}
 	}
 	return nil
 }
 
+func hasLabel(view *DataViewConfig, name string) bool {
+	for _, label := range view.Labels {
+		if label.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
 // ValidateDataSource validates the DataSourceConfig.
 func (v *Validator) ValidateDataSource(ds *DataSourceConfig) error {
 	if ds.Name == "" {
@@ -163,5 +387,84 @@ func (v *Validator) ValidateDataSource(ds *DataSourceConfig) error {
 	if ds.DSN == "" {
 		return fmt.Errorf("data source '%s' DSN is required", ds.Name)
 	}
+	if err := validateCsvOptions(ds.Csv); err != nil {
+		return fmt.Errorf("data source '%s' %w", ds.Name, err)
+	}
+	if err := validateHttpOptions(ds.Http); err != nil {
+		return fmt.Errorf("data source '%s' %w", ds.Name, err)
+	}
+	if err := validateFetchPolicy(ds.Fetch); err != nil {
+		return fmt.Errorf("data source '%s' %w", ds.Name, err)
+	}
+	// Each fetch retry would run every HTTP retry again
+	if ds.Http != nil && ds.Http.Retries > 0 && ds.Fetch != nil && ds.Fetch.Retries > 0 {
+		return fmt.Errorf("data source '%s' sets both http retries and fetch retries; use one of them", ds.Name)
+	}
+	return nil
+}
+
+func validateFetchPolicy(policy *FetchPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	if policy.Retries < 0 {
+		return fmt.Errorf("fetch retries must not be negative")
+	}
+	for name, value := range map[string]string{"retryBackoff": policy.RetryBackoff, "maxBackoff": policy.MaxBackoff, "timeout": policy.Timeout} {
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			return fmt.Errorf("fetch %s '%s' is not a valid duration", name, value)
+		}
+	}
+	return nil
+}
+
+func validateHttpOptions(opts *HttpSourceOptions) error {
+	if opts == nil {
+		return nil
+	}
+	for name, value := range map[string]string{"timeout": opts.Timeout, "retryBackoff": opts.RetryBackoff} {
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d < 0 {
+			return fmt.Errorf("http %s '%s' is not a valid duration", name, value)
+		}
+	}
+	if opts.Retries < 0 {
+		return fmt.Errorf("http retries must not be negative")
+	}
+	if p := opts.Pagination; p != nil {
+		switch p.Style {
+		case PaginationPage, PaginationOffset, PaginationLink:
+			// OK
+		case PaginationCursor:
+			if p.CursorPath == "" {
+				return fmt.Errorf("http cursor pagination requires a cursorPath")
+			}
+		default:
+			return fmt.Errorf("http pagination has invalid style '%s'", p.Style)
+		}
+		if p.PageSize < 0 || p.MaxPages < 0 {
+			return fmt.Errorf("http pagination pageSize and maxPages must not be negative")
+		}
+	}
+	return nil
+}
+
+func validateCsvOptions(opts *CsvOptions) error {
+	if opts == nil {
+		return nil
+	}
+	for name, value := range map[string]string{"delimiter": opts.Delimiter, "quote": opts.Quote, "comment": opts.Comment} {
+		if utf8.RuneCountInString(value) > 1 {
+			return fmt.Errorf("csv %s must be a single character, got '%s'", name, value)
+		}
+	}
+	if opts.Delimiter != "" && opts.Delimiter == opts.Quote {
+		return fmt.Errorf("csv delimiter and quote must differ")
+	}
 	return nil
 }
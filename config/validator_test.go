@@ -135,6 +135,142 @@ func TestValidator_ValidateWorkbook(t *testing.T) {
 			wantErr: true,
 			errMsg:  "must have both vertical and horizontal header blocks",
 		},
+		{
+			name: "Invalid Sort Direction",
+			wb: &WorkbookConfig{
+				Name:      "Report",
+				Template:  "tpl.xlsx",
+				OutputDir: "out",
+				Sheets: []SheetConfig{
+					{
+						Name: "Sheet1",
+						Blocks: []BlockConfig{
+							{
+								Name:         "Block1",
+								Type:         BlockTypeValue,
+								Range:        CellRange{Ref: "A1"},
+								DataViewName: "view1",
+								Sort:         []SortConfig{{Label: "month", Direction: "up"}},
+							},
+						},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "invalid direction 'up'",
+		},
+		{
+			name: "Sort On Static Sheet",
+			wb: &WorkbookConfig{
+				Name:      "Report",
+				Template:  "tpl.xlsx",
+				OutputDir: "out",
+				Sheets:    []SheetConfig{{Name: "S1", Sort: []SortConfig{{Label: "month"}}}},
+			},
+			wantErr: true,
+			errMsg:  "sort requires a dynamic sheet",
+		},
+		{
+			name: "Invalid Range",
+			wb: &WorkbookConfig{
+				Name:      "Report",
+				Template:  "tpl.xlsx",
+				OutputDir: "out",
+				Sheets: []SheetConfig{{
+					Name:   "Sheet1",
+					Blocks: []BlockConfig{{Name: "Block1", Type: BlockTypeValue, Range: CellRange{Ref: "A0:B2"}}},
+				}},
+			},
+			wantErr: true,
+			errMsg:  "sheet 0 ('Sheet1') error: block 0 error: block 'Block1' has invalid range 'A0:B2'",
+		},
+		{
+			name: "Overlapping Blocks",
+			wb: &WorkbookConfig{
+				Name:      "Report",
+				Template:  "tpl.xlsx",
+				OutputDir: "out",
+				Sheets: []SheetConfig{{
+					Name: "Sheet1",
+					Blocks: []BlockConfig{
+						{Name: "Top", Type: BlockTypeValue, Range: CellRange{Ref: "A1:C3"}},
+						{Name: "Side", Type: BlockTypeValue, Range: CellRange{Ref: "E1:E9"}},
+						{Name: "Bottom", Type: BlockTypeValue, Range: CellRange{Ref: "B3:D4"}},
+					},
+				}},
+			},
+			wantErr: true,
+			errMsg:  "sheet 'Sheet1' block 'Bottom' (B3:D4) overlaps block 'Top' (A1:C3) at B3:C3",
+		},
+		{
+			name: "Overlapping Blocks Allowed",
+			wb: &WorkbookConfig{
+				Name:      "Report",
+				Template:  "tpl.xlsx",
+				OutputDir: "out",
+				Sheets: []SheetConfig{{
+					Name:         "Sheet1",
+					AllowOverlap: true,
+					Blocks: []BlockConfig{
+						{Name: "Top", Type: BlockTypeValue, Range: CellRange{Ref: "A1:C3"}},
+						{Name: "Bottom", Type: BlockTypeValue, Range: CellRange{Ref: "B3:D4"}},
+					},
+				}},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Valid Matrix",
+			wb:      matrixWorkbook("A2:A2", "B1:C1", "B2:C2"),
+			wantErr: false,
+		},
+		{
+			name:    "Matrix Sub-block Outside",
+			wb:      matrixWorkbook("A2:A2", "B1:C1", "B2:D2"),
+			wantErr: true,
+			errMsg:  "matrix block 'Matrix1' sub-block 'Cell' (B2:D2) lies outside the matrix range A1:C2",
+		},
+		{
+			name:    "Matrix Template Misaligned With Rows",
+			wb:      matrixWorkbook("A1:A1", "B1:C1", "B2:C2"),
+			wantErr: true,
+			errMsg:  "template block 'Cell' (B2:C2) is not aligned with the rows of vertical header 'Rows' (A1:A1)",
+		},
+		{
+			name:    "Matrix Template Misaligned With Columns",
+			wb:      matrixWorkbook("A2:A2", "B1:B1", "B2:C2"),
+			wantErr: true,
+			errMsg:  "template block 'Cell' (B2:C2) is not aligned with the columns of horizontal header 'Cols' (B1:B1)",
+		},
+		{
+			name: "Matrix Header Without Direction",
+			wb: func() *WorkbookConfig {
+				wb := matrixWorkbook("A2:A2", "B1:C1", "B2:C2")
+				wb.Sheets[0].Blocks[0].SubBlocks[0].Direction = ""
+				return wb
+			}(),
+			wantErr: true,
+			errMsg:  "header block 'Rows' must set direction 'vertical' or 'horizontal'",
+		},
+		{
+			name: "Matrix Duplicate Vertical Header",
+			wb: func() *WorkbookConfig {
+				wb := matrixWorkbook("A2:A2", "B1:C1", "B2:C2")
+				matrix := &wb.Sheets[0].Blocks[0]
+				matrix.SubBlocks = append(matrix.SubBlocks, BlockConfig{
+					Name: "MoreRows", Type: BlockTypeHeader, Direction: DirectionVertical, Range: CellRange{Ref: "A2:A2"},
+				})
+				return wb
+			}(),
+			wantErr: true,
+			errMsg:  "matrix block 'Matrix1' has more than one vertical header block",
+		},
+		{
+			name:    "Matrix Sub-blocks Overlap",
+			wb:      matrixWorkbook("A2:B2", "B1:C1", "B2:C2"),
+			wantErr: true,
+			errMsg:  "in block 'Matrix1': block 'Cell' (B2:C2) overlaps block 'Rows' (A2:B2) at B2:B2",
+		},
 	}
 
 	for _, tt := range tests {
@@ -198,6 +334,52 @@ func TestValidator_ValidateDataView(t *testing.T) {
 			wantErr: true,
 			errMsg:  "unknown DataSource",
 		},
+		{
+			name: "Valid Filters",
+			dv: &DataViewConfig{
+				Name:       "view1",
+				DataSource: "ds1",
+				Labels:     []LabelConfig{{Name: "date", Column: "DT", Type: LabelTypeDate}},
+				Filters: []FilterConfig{
+					{Label: "date", Op: FilterOpGe, Value: "${start}"},
+					{Label: "date", Op: FilterOpNotNull},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Invalid Filter Op",
+			dv: &DataViewConfig{
+				Name:       "view1",
+				DataSource: "ds1",
+				Labels:     []LabelConfig{{Name: "date", Column: "DT"}},
+				Filters:    []FilterConfig{{Label: "date", Op: ">=", Value: "2025-01-01"}},
+			},
+			wantErr: true,
+			errMsg:  "invalid op '>='",
+		},
+		{
+			name: "Filter On Unknown Label",
+			dv: &DataViewConfig{
+				Name:       "view1",
+				DataSource: "ds1",
+				Labels:     []LabelConfig{{Name: "date", Column: "DT"}},
+				Filters:    []FilterConfig{{Label: "region", Op: FilterOpEq, Value: "EU"}},
+			},
+			wantErr: true,
+			errMsg:  "unknown label 'region'",
+		},
+		{
+			name: "Null Filter With Value",
+			dv: &DataViewConfig{
+				Name:       "view1",
+				DataSource: "ds1",
+				Labels:     []LabelConfig{{Name: "date", Column: "DT"}},
+				Filters:    []FilterConfig{{Label: "date", Op: FilterOpNull, Value: "x"}},
+			},
+			wantErr: true,
+			errMsg:  "takes no value",
+		},
 		{
 			name: "Invalid Label",
 			dv: &DataViewConfig{
@@ -210,6 +392,68 @@ func TestValidator_ValidateDataView(t *testing.T) {
 			wantErr: true,
 			errMsg:  "label 0 name is required",
 		},
+		{
+			name: "Invalid Label Type",
+			dv: &DataViewConfig{
+				Name:       "view1",
+				DataSource: "ds1",
+				Labels: []LabelConfig{
+					{Name: "l1", Column: "c1", Type: "money"},
+				},
+			},
+			wantErr: true,
+			errMsg:  "invalid type 'money'",
+		},
+		{
+			name: "DynamoDB Sort Key Without Partition Key",
+			dv: &DataViewConfig{
+				Name:       "view1",
+				DataSource: "ds1",
+				DynamoDB:   &DynamoDBViewConfig{SortKey: "month"},
+			},
+			wantErr: true,
+			errMsg:  "requires a partitionKey",
+		},
+		{
+			name: "Invalid DynamoDB Attribute Type",
+			dv: &DataViewConfig{
+				Name:       "view1",
+				DataSource: "ds1",
+				DynamoDB:   &DynamoDBViewConfig{AttributeTypes: map[string]string{"year": "INT"}},
+			},
+			wantErr: true,
+			errMsg:  "invalid DynamoDB type 'INT'",
+		},
+		{
+			name: "Invalid JSON Format",
+			dv: &DataViewConfig{
+				Name:       "view1",
+				DataSource: "ds1",
+				Json:       &JsonOptions{Format: "xml"},
+			},
+			wantErr: true,
+			errMsg:  "invalid json format 'xml'",
+		},
+		{
+			name: "Excel Range And Table",
+			dv: &DataViewConfig{
+				Name:       "view1",
+				DataSource: "ds1",
+				Excel:      &ExcelOptions{Range: "Data", Table: "Sales"},
+			},
+			wantErr: true,
+			errMsg:  "mutually exclusive",
+		},
+		{
+			name: "Negative DynamoDB Total Segments",
+			dv: &DataViewConfig{
+				Name:       "view1",
+				DataSource: "ds1",
+				DynamoDB:   &DynamoDBViewConfig{TotalSegments: -1},
+			},
+			wantErr: true,
+			errMsg:  "totalSegments must be between",
+		},
 	}
 
 	for _, tt := range tests {
@@ -261,6 +505,52 @@ func TestValidator_ValidateDataSource(t *testing.T) {
 			wantErr: true,
 			errMsg:  "DSN is required",
 		},
+		{
+			name: "Invalid CSV Delimiter",
+			ds: &DataSourceConfig{
+				Name: "ds1", Driver: "csv", DSN: "./data",
+				Csv: &CsvOptions{Delimiter: ";;"},
+			},
+			wantErr: true,
+			errMsg:  "csv delimiter must be a single character",
+		},
+		{
+			name: "HTTP Cursor Pagination Without Path",
+			ds: &DataSourceConfig{
+				Name: "ds1", Driver: "http", DSN: "https://api.example.com",
+				Http: &HttpSourceOptions{Pagination: &HttpPagination{Style: PaginationCursor}},
+			},
+			wantErr: true,
+			errMsg:  "requires a cursorPath",
+		},
+		{
+			name: "HTTP Invalid Timeout",
+			ds: &DataSourceConfig{
+				Name: "ds1", Driver: "http", DSN: "https://api.example.com",
+				Http: &HttpSourceOptions{Timeout: "ten seconds"},
+			},
+			wantErr: true,
+			errMsg:  "http timeout 'ten seconds' is not a valid duration",
+		},
+		{
+			name: "Negative Fetch Retries",
+			ds: &DataSourceConfig{
+				Name: "ds1", Driver: "mysql", DSN: "dsn",
+				Fetch: &FetchPolicy{Retries: -1},
+			},
+			wantErr: true,
+			errMsg:  "fetch retries must not be negative",
+		},
+		{
+			name: "HTTP And Fetch Retries",
+			ds: &DataSourceConfig{
+				Name: "ds1", Driver: "http", DSN: "https://api.example.com",
+				Http:  &HttpSourceOptions{Retries: 2},
+				Fetch: &FetchPolicy{Retries: 3},
+			},
+			wantErr: true,
+			errMsg:  "sets both http retries and fetch retries",
+		},
 	}
 
 	for _, tt := range tests {
@@ -276,3 +566,26 @@ func TestValidator_ValidateDataSource(t *testing.T) {
 		})
 	}
 }
+
+// matrixWorkbook returns a workbook with a matrix A1:C2 made of the given
+// vertical header, horizontal header and template ranges.
+func matrixWorkbook(rows, cols, cell string) *WorkbookConfig {
+	return &WorkbookConfig{
+		Name:      "Report",
+		Template:  "tpl.xlsx",
+		OutputDir: "out",
+		Sheets: []SheetConfig{{
+			Name: "Sheet1",
+			Blocks: []BlockConfig{{
+				Name:  "Matrix1",
+				Type:  BlockTypeMatrix,
+				Range: CellRange{Ref: "A1:C2"},
+				SubBlocks: []BlockConfig{
+					{Name: "Rows", Type: BlockTypeHeader, Direction: DirectionVertical, Range: CellRange{Ref: rows}},
+					{Name: "Cols", Type: BlockTypeHeader, Direction: DirectionHorizontal, Range: CellRange{Ref: cols}},
+					{Name: "Cell", Type: BlockTypeValue, Range: CellRange{Ref: cell}},
+				},
+			}},
+		}},
+	}
+}
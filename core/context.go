@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"fibr-gen/config"
 	"fmt"
 	"log/slog"
@@ -14,14 +15,55 @@ type DataFetcher interface {
 	Fetch(viewName string, params map[string]string) ([]map[string]interface{}, error)
 }
 
+// ContextDataFetcher is a DataFetcher whose fetches honour the deadline and
+// cancellation of a context.Context.
+type ContextDataFetcher interface {
+	DataFetcher
+	FetchContext(ctx context.Context, viewName string, params map[string]string) ([]map[string]interface{}, error)
+}
+
+// WithContext adapts a DataFetcher to ContextDataFetcher. Fetchers that already
+// implement it are returned unchanged; for others the context is only checked
+// before fetching, as their Fetch cannot be interrupted.
+func WithContext(fetcher DataFetcher) ContextDataFetcher {
+	if cf, ok := fetcher.(ContextDataFetcher); ok {
+		return cf
+	}
+	return contextFetcherAdapter{fetcher}
+}
+
+type contextFetcherAdapter struct {
+	DataFetcher
+}
+
+func (a contextFetcherAdapter) FetchContext(ctx context.Context, viewName string, params map[string]string) ([]map[string]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.Fetch(viewName, params)
+}
+
+// now is the clock dynamic dates are resolved against, replaced by tests.
+var now = time.Now
+
 // GenerationContext holds the state for the current generation process.
 type GenerationContext struct {
 	WorkbookConfig *config.WorkbookConfig
 	Parameters     map[string]string
 	Fetcher        DataFetcher
 	ConfigProvider config.Provider
-	// Cache for loaded DataViews (to avoid re-fetching or re-creating)
+	// Cache of fetched rows by view and the params it uses, shared by every fetch of the run
+	Cache *FetchCache
+	// Formatters for "{label|format}" placeholders
+	Formatters *FormatterRegistry
+	// Preloaded DataViews by view name, returned (as copies) by GetDataView.
+	//
+	// Deprecated: fetched rows are cached by Cache, keyed by the params a view
+	// uses; generation no longer stores views here.
 	LoadedViews map[string]*DataView
+
+	views  map[string]*DataView // shared, indexed views over cached rows, by fetch key
+	runCtx context.Context      // set by Generator.GenerateContext for the duration of a run
 }
 
 // NewGenerationContext creates a new context.
@@ -39,7 +81,7 @@ func NewGenerationContext(wb *config.WorkbookConfig, provider config.Provider, f
 
 	// Handle archive_date special rule
 	if wb.ArchiveRule != "" {
-		if val, err := ParseDynamicDate(wb.ArchiveRule, time.Now()); err == nil {
+		if val, err := ParseDynamicDate(wb.ArchiveRule, now()); err == nil {
 			mergedParams["archive_date"] = val
 		}
 	}
@@ -47,7 +89,7 @@ func NewGenerationContext(wb *config.WorkbookConfig, provider config.Provider, f
 	// Process all dynamic parameters
 	for k, v := range mergedParams {
 		if strings.HasPrefix(v, "$date:") {
-			if val, err := ParseDynamicDate(v, time.Now()); err == nil {
+			if val, err := ParseDynamicDate(v, now()); err == nil {
 				mergedParams[k] = val
 			}
 		}
@@ -58,44 +100,80 @@ func NewGenerationContext(wb *config.WorkbookConfig, provider config.Provider, f
 		Parameters:     mergedParams,
 		Fetcher:        fetcher,
 		ConfigProvider: provider,
+		Cache:          NewFetchCache(),
+		Formatters:     DefaultFormatters,
 		LoadedViews:    make(map[string]*DataView),
 	}
 }
 
+// Context returns the context of the current generation run, or
+// context.Background() outside of Generator.GenerateContext.
+func (ctx *GenerationContext) Context() context.Context {
+	if ctx.runCtx != nil {
+		return ctx.runCtx
+	}
+	return context.Background()
+}
+
+// fetch loads a view's rows through the cache and fetcher, honouring the run's context.
+func (ctx *GenerationContext) fetch(viewName string, params map[string]string) ([]map[string]interface{}, error) {
+	if ctx.Cache == nil {
+		return WithContext(ctx.Fetcher).FetchContext(ctx.Context(), viewName, params)
+	}
+	return ctx.Cache.Fetch(ctx.Context(), ctx.Fetcher, viewName, params)
+}
+
 // GetDataView resolves and loads a DataView by name.
-// It uses caching to ensure the same view (and its data) is reused if needed,
-// but note that DataView is mutable (can be filtered).
-// For distinct operations, we typically need a fresh copy or the raw data.
-// In this design, GetDataView returns a NEW instance populated with data,
+// Its rows come from the fetch cache, so the view is fetched once per run
+// for the same parameters. Note that DataView is mutable (can be filtered):
+// GetDataView returns a NEW instance holding a copy of the cached data.
 func (ctx *GenerationContext) GetDataView(viewName string) (*DataView, error) {
-	// 1. Check Cache
-	if cachedView, ok := ctx.LoadedViews[viewName]; ok {
-		// Return a DEEP COPY to ensure isolation
-		// The caller can filter/modify the copy without affecting the cache
-		return cachedView.Copy(), nil
+	if preloaded, ok := ctx.LoadedViews[viewName]; ok {
+		return preloaded.Copy(), nil
 	}
+	vv, err := ctx.sharedDataView(viewName)
+	if err != nil {
+		return nil, err
+	}
+	// The caller can filter/modify the copy without affecting the cache
+	return vv.Copy(), nil
+}
 
-	// 2. Resolve Config
+// sharedDataView returns the read-only DataView of a view for the run's
+// parameters. With a fetch cache the same instance, and so its lookup
+// indexes, is reused for as long as the cached rows are.
+func (ctx *GenerationContext) sharedDataView(viewName string) (*DataView, error) {
+	// 1. Resolve Config
 	conf, err := ctx.ConfigProvider.GetDataViewConfig(viewName)
 	if err != nil {
 		return nil, err
 	}
 
-	// 3. Fetch Data (Full Load)
-	// Note: Fetcher.Fetch might already filter based on global params?
-	// Currently Fetcher.Fetch takes params.
-	data, err := ctx.Fetcher.Fetch(conf.Name, ctx.Parameters)
+	// 2. Fetch Data (Full Load, cached by view and the params it uses)
+	params := fetchParams(ctx.ConfigProvider, conf, ctx.Parameters)
+	data, err := ctx.fetch(conf.Name, params)
 	if err != nil {
 		return nil, err
 	}
+	// Reuse the view built over the cached rows
+	key := fetchKey(conf.Name, params)
+	if vv, ok := ctx.views[key]; ok && vv.Config == conf && ctx.Cache != nil {
+		return vv, nil
+	}
 
-	// 4. Create and Cache DataView
-	// This instance holds the ORIGINAL full data
-	vv := NewDataView(conf, data)
-	ctx.LoadedViews[viewName] = vv
-
-	// 5. Return Copy
-	return vv.Copy(), nil
+	// 3. Apply the view's Filters (again, for fetchers that pushed them down)
+	filters, err := viewFilters(conf, params)
+	if err != nil {
+		return nil, err
+	}
+	vv := NewDataView(conf, applyFilters(data, filters))
+	if ctx.Cache != nil {
+		if ctx.views == nil {
+			ctx.views = make(map[string]*DataView)
+		}
+		ctx.views[key] = vv
+	}
+	return vv, nil
 }
 
 // GetBlockData fetches data for a specific block based on its DataView.
@@ -109,28 +187,41 @@ func (ctx *GenerationContext) GetBlockDataWithParams(block *config.BlockConfig,
 		return nil, nil // No data source
 	}
 
-	// Load DataView
-	vv, err := ctx.GetDataView(block.DataViewName)
+	// Load the shared DataView (no copy: rows are only read)
+	vv, err := ctx.sharedDataView(block.DataViewName)
 	if err != nil {
 		return nil, err
 	}
 
 	// Apply Params Filter (if any specific to this block/context)
 	// The Fetcher might have already filtered by GLOBAL params.
-	// But `params` here might contain loop variables (e.g. emp_id=E001).
-	// So we should filter the DataView memory data.
-	vv.Filter(params)
+	// But `params` here might contain loop variables (e.g. emp_id=E001),
+	// so we look the matching rows up in the DataView's indexes.
+	data := vv.Lookup(params)
+	if len(block.Filters) > 0 {
+		filters, err := resolveFilters(vv.Config, block.Filters, params)
+		if err != nil {
+			return nil, fmt.Errorf("block '%s': %w", block.Name, err)
+		}
+		data = applyFilters(data, filters)
+	}
+	if len(block.Sort) > 0 {
+		// Sort before Distinct, so header axes follow the sort order
+		if data, err = sortRows(vv.Config, data, block.Sort); err != nil {
+			return nil, fmt.Errorf("block '%s': %w", block.Name, err)
+		}
+	}
 
 	// Apply Distinct logic if it's an Header Block
 	var finalData []map[string]interface{}
 	if block.Type == config.BlockTypeHeader {
-		result, err := ctx.distinctData(vv.Data, block, vv)
+		result, err := ctx.distinctData(data, block, vv)
 		if err != nil {
 			return nil, err
 		}
 		finalData = result
 	} else {
-		finalData = vv.Data
+		finalData = data
 	}
 
 	// Apply RowLimit if configured
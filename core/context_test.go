@@ -1,18 +1,22 @@
 package core
 
 import (
+	"context"
+	"errors"
 	"fibr-gen/config"
 	"testing"
 	"time"
 )
 
 type countingFetcher struct {
-	calls int
-	data  map[string][]map[string]interface{}
+	calls  int
+	params []map[string]string // of each call
+	data   map[string][]map[string]interface{}
 }
 
 func (f *countingFetcher) Fetch(viewName string, params map[string]string) ([]map[string]interface{}, error) {
 	f.calls++
+	f.params = append(f.params, params)
 	return f.data[viewName], nil
 }
 
@@ -76,3 +80,55 @@ func TestGenerationContext_GetDataViewCaching(t *testing.T) {
 		t.Fatalf("fetcher calls = %d, want 1", fetcher.calls)
 	}
 }
+
+type ctxRecordingFetcher struct {
+	countingFetcher
+	gotCtx context.Context
+}
+
+func (f *ctxRecordingFetcher) FetchContext(ctx context.Context, viewName string, params map[string]string) ([]map[string]interface{}, error) {
+	f.gotCtx = ctx
+	return f.Fetch(viewName, params)
+}
+
+func TestWithContext(t *testing.T) {
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "run-1")
+
+	aware := &ctxRecordingFetcher{}
+	if _, err := WithContext(aware).FetchContext(ctx, "view1", nil); err != nil {
+		t.Fatalf("FetchContext error: %v", err)
+	}
+	if aware.gotCtx != ctx {
+		t.Fatal("context-aware fetcher did not receive the caller's context")
+	}
+
+	plain := &countingFetcher{}
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := WithContext(plain).FetchContext(cancelled, "view1", nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("FetchContext error = %v, want context.Canceled", err)
+	}
+	if plain.calls != 0 {
+		t.Fatalf("fetcher calls = %d after cancellation, want 0", plain.calls)
+	}
+}
+
+// BenchmarkGetBlockDataWithParams_MatrixCell resolves one intersection cell
+// of a 500 x 36 matrix through the generation context.
+func BenchmarkGetBlockDataWithParams_MatrixCell(b *testing.B) {
+	v := matrixView(500, 36)
+	registry := config.NewMemoryConfigRegistry(map[string]*config.DataViewConfig{v.Config.Name: v.Config}, nil)
+	fetcher := &countingFetcher{data: map[string][]map[string]interface{}{v.Config.Name: v.Data}}
+	ctx := NewGenerationContext(&config.WorkbookConfig{}, registry, fetcher, nil)
+	block := &config.BlockConfig{Name: "Data", Type: config.BlockTypeValue, DataViewName: v.Config.Name}
+	cells := matrixCellParams(500, 36)
+
+	i := 0
+	for b.Loop() {
+		if _, err := ctx.GetBlockDataWithParams(block, cells[i%len(cells)]); err != nil {
+			b.Fatalf("GetBlockDataWithParams error: %v", err)
+		}
+		i++
+	}
+}
@@ -1,72 +1,242 @@
 package core
 
 import (
+	"bufio"
 	"encoding/csv"
+	"fibr-gen/config"
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/htmlindex"
 )
 
 // CsvDataFetcher implements DataFetcher using CSV files.
-// It maps viewName to a CSV file path.
+// It maps a view to <RootDir>/<Table>.csv, where Table defaults to the view name,
+// or to the files matching the Path of its CSV options.
 type CsvDataFetcher struct {
-	RootDir string
+	RootDir  string
+	Options  *config.CsvOptions // Optional: data source dialect, overridden by view options
+	Provider config.Provider    // Optional: resolves view configs (Table, CSV options)
 }
 
 func NewCsvDataFetcher(rootDir string) *CsvDataFetcher {
 	return &CsvDataFetcher{RootDir: rootDir}
 }
 
+// Fetch reads the view's CSV files. When a glob matches several files their
+// rows are concatenated in file name order.
 func (f *CsvDataFetcher) Fetch(viewName string, params map[string]string) ([]map[string]interface{}, error) {
-	// Assume viewName is the filename without extension, or we can use a mapping.
-	// For simplicity, let's assume viewName + ".csv" exists in RootDir.
-	filePath := filepath.Join(f.RootDir, viewName+".csv")
+	conf, err := lookupViewConfig(f.Provider, viewName)
+	if err != nil {
+		return nil, err
+	}
+	var viewOpts *config.CsvOptions
+	if conf != nil {
+		viewOpts = conf.Csv
+	}
+	opts := mergeCsvOptions(f.Options, viewOpts)
 
-	file, err := os.Open(filePath)
+	paths, err := resolveViewFiles(f.RootDir, viewName, conf, opts.Path, ".csv", params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open csv file %s: %w", filePath, err)
+		return nil, err
 	}
-	defer file.Close()
 
-	reader := csv.NewReader(file)
-	// Read all records
-	records, err := reader.ReadAll()
+	var result []map[string]interface{}
+	for _, path := range paths {
+		rows, err := readCsvFile(path, &opts)
+		if err != nil {
+			return nil, err
+		}
+		// Simple filter: if a label-mapped param key matches a column name, filter by value.
+		result = append(result, filterRows(rows, conf, params)...)
+	}
+
+	return result, nil
+}
+
+// mergeCsvOptions overlays the options set in override onto base.
+func mergeCsvOptions(base, override *config.CsvOptions) config.CsvOptions {
+	var merged config.CsvOptions
+	for _, o := range []*config.CsvOptions{base, override} {
+		if o == nil {
+			continue
+		}
+		if o.Delimiter != "" {
+			merged.Delimiter = o.Delimiter
+		}
+		if o.Quote != "" {
+			merged.Quote = o.Quote
+		}
+		if o.Comment != "" {
+			merged.Comment = o.Comment
+		}
+		if o.Encoding != "" {
+			merged.Encoding = o.Encoding
+		}
+		if o.Header != nil {
+			merged.Header = o.Header
+		}
+		if len(o.Columns) > 0 {
+			merged.Columns = o.Columns
+		}
+		if o.Path != "" {
+			merged.Path = o.Path
+		}
+	}
+	return merged
+}
+
+// readCsvFile decodes a CSV file into rows keyed by column name.
+func readCsvFile(path string, opts *config.CsvOptions) ([]map[string]interface{}, error) {
+	file, err := os.Open(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read csv content: %w", err)
+		return nil, fmt.Errorf("failed to open csv file %s: %w", path, err)
 	}
+	defer file.Close()
 
-	if len(records) < 1 {
-		return nil, nil // Empty
+	var r io.Reader = file
+	if opts.Encoding != "" {
+		enc, err := htmlindex.Get(opts.Encoding)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported csv encoding '%s': %w", opts.Encoding, err)
+		}
+		r = enc.NewDecoder().Reader(file)
+	}
+	br := bufio.NewReader(r)
+	// Skip a byte order mark, which would otherwise stick to the first column name.
+	if c, _, err := br.ReadRune(); err == nil && c != '\uFEFF' {
+		br.UnreadRune()
 	}
 
-	header := records[0]
-	var result []map[string]interface{}
+	records, err := readCsvRecords(br, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv content of %s: %w", path, err)
+	}
 
-	for i := 1; i < len(records); i++ {
-		row := records[i]
+	header := opts.Columns
+	hasHeader := len(opts.Columns) == 0
+	if opts.Header != nil {
+		hasHeader = *opts.Header
+	}
+	if hasHeader {
+		if len(records) < 1 {
+			return nil, nil // Empty
+		}
+		if header == nil {
+			header = records[0]
+		}
+		records = records[1:]
+	}
+	if header == nil {
+		return nil, fmt.Errorf("csv file %s has no header row; set columns", path)
+	}
+
+	rows := make([]map[string]interface{}, 0, len(records))
+	for _, row := range records {
 		item := make(map[string]interface{})
 		for j, col := range row {
 			if j < len(header) {
 				item[header[j]] = col
 			}
 		}
-		// TODO: Implement filtering based on params if needed for CSV?
-		// Simple filter: if param key matches a column name, filter by value.
-		match := true
-		for k, v := range params {
-			if colVal, hasCol := item[k]; hasCol {
-				// Convert both to string for comparison (already string in CSV)
-				if fmt.Sprintf("%v", colVal) != v {
-					match = false
-					break
+		rows = append(rows, item)
+	}
+	return rows, nil
+}
+
+// readCsvRecords parses all records using the configured dialect.
+func readCsvRecords(r *bufio.Reader, opts *config.CsvOptions) ([][]string, error) {
+	delim := optionRune(opts.Delimiter, ',')
+	quote := optionRune(opts.Quote, '"')
+	comment := optionRune(opts.Comment, 0)
+
+	if quote != '"' {
+		return readQuotedCsv(r, delim, quote, comment)
+	}
+	reader := csv.NewReader(r)
+	reader.Comma = delim
+	reader.Comment = comment
+	return reader.ReadAll()
+}
+
+func optionRune(s string, def rune) rune {
+	if s == "" {
+		return def
+	}
+	c, _ := utf8.DecodeRuneInString(s)
+	return c
+}
+
+// readQuotedCsv parses CSV whose quote character is not '"', which encoding/csv
+// does not support. A doubled quote inside a quoted field is a literal quote.
+func readQuotedCsv(r *bufio.Reader, delim, quote, comment rune) ([][]string, error) {
+	var records [][]string
+	var record []string
+	var field strings.Builder
+	inQuotes, atLineStart := false, true
+
+	for {
+		c, _, err := r.ReadRune()
+		if err == io.EOF {
+			if inQuotes {
+				return nil, fmt.Errorf("unterminated quoted field in record %d", len(records)+1)
+			}
+			if !atLineStart {
+				records = append(records, append(record, field.String()))
+			}
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if inQuotes {
+			if c == quote {
+				if next, _, err := r.ReadRune(); err == nil {
+					if next == quote {
+						field.WriteRune(quote)
+						continue
+					}
+					r.UnreadRune()
 				}
+				inQuotes = false
+				continue
 			}
+			field.WriteRune(c)
+			continue
 		}
 
-		if match {
-			result = append(result, item)
+		if atLineStart {
+			if c == '\r' || c == '\n' {
+				continue // blank line
+			}
+			if comment != 0 && c == comment {
+				if _, err := r.ReadString('\n'); err != nil && err != io.EOF {
+					return nil, err
+				}
+				continue
+			}
+			atLineStart = false
 		}
-	}
 
-	return result, nil
+		switch c {
+		case quote:
+			inQuotes = true
+		case delim:
+			record = append(record, field.String())
+			field.Reset()
+		case '\r':
+			// Part of a \r\n line ending.
+		case '\n':
+			records = append(records, append(record, field.String()))
+			record = nil
+			field.Reset()
+			atLineStart = true
+		default:
+			field.WriteRune(c)
+		}
+	}
 }
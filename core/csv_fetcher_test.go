@@ -1,9 +1,12 @@
 package core
 
 import (
+	"fibr-gen/config"
 	"os"
 	"path/filepath"
 	"testing"
+
+	"golang.org/x/text/encoding/japanese"
 )
 
 func TestCsvDataFetcher_Fetch(t *testing.T) {
@@ -26,3 +29,114 @@ func TestCsvDataFetcher_Fetch(t *testing.T) {
 		t.Fatalf("name = %v, want Bob", rows[0]["name"])
 	}
 }
+
+func TestCsvDataFetcher_FetchUsesViewTable(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "sales.csv"), []byte("region,amount\nEU,10\nUS,20\n"), 0644); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+
+	views := map[string]*config.DataViewConfig{
+		"monthly_sales_eu": {Name: "monthly_sales_eu", Table: "sales"},
+		"monthly_sales_us": {Name: "monthly_sales_us", Table: "sales.csv"},
+	}
+	fetcher := NewCsvDataFetcher(dir)
+	fetcher.Provider = config.NewMemoryConfigRegistry(views, nil)
+
+	for _, view := range []string{"monthly_sales_eu", "monthly_sales_us"} {
+		rows, err := fetcher.Fetch(view, nil)
+		if err != nil {
+			t.Fatalf("Fetch %s error: %v", view, err)
+		}
+		if len(rows) != 2 {
+			t.Fatalf("%s rows = %d, want 2", view, len(rows))
+		}
+	}
+}
+
+func TestCsvDataFetcher_FetchFiltersOnLabelParamsOnly(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "staff.csv"), []byte("dept,name,env\nD1,Alice,prod\nD2,Bob,dev\n"), 0644); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+
+	views := map[string]*config.DataViewConfig{
+		"v_staff": {Name: "v_staff", Table: "staff", Labels: []config.LabelConfig{
+			{Name: "department", Column: "dept"},
+			{Name: "name", Column: "name"},
+		}},
+	}
+	fetcher := NewCsvDataFetcher(dir)
+	fetcher.Provider = config.NewMemoryConfigRegistry(views, nil)
+
+	// "env" matches a column but no label, so it does not filter rows.
+	rows, err := fetcher.Fetch("v_staff", map[string]string{"env": "prod"})
+	if err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("rows = %v, want both rows", rows)
+	}
+
+	// Label columns still filter.
+	rows, err = fetcher.Fetch("v_staff", map[string]string{"env": "prod", "dept": "D2"})
+	if err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["name"] != "Bob" {
+		t.Fatalf("rows = %v, want Bob", rows)
+	}
+}
+
+func TestCsvDataFetcher_FetchWithDialect(t *testing.T) {
+	dir := t.TempDir()
+	// Shift-JIS encoded, semicolon separated, quoted with ' and without a header row.
+	sjis, err := japanese.ShiftJIS.NewEncoder().String("D1;'山田; 太郎'\n# comment\nD2;'O''Brien'\n")
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "staff_2025-01.csv"), []byte(sjis), 0644); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+	// UTF-8 with BOM and a header row.
+	if err := os.WriteFile(filepath.Join(dir, "sales_2025-01.csv"), []byte("\uFEFFregion;amount\nEU;10\n"), 0644); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sales_2025-02.csv"), []byte("\uFEFFregion;amount\nUS;20\n"), 0644); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+
+	views := map[string]*config.DataViewConfig{
+		"v_staff": {Name: "v_staff", Csv: &config.CsvOptions{
+			Quote:    "'",
+			Comment:  "#",
+			Encoding: "shift_jis",
+			Columns:  []string{"dept", "name"},
+			Path:     "staff_${month}.csv",
+		}},
+		"v_sales": {Name: "v_sales", Csv: &config.CsvOptions{Path: "sales_*.csv"}},
+	}
+	fetcher := NewCsvDataFetcher(dir)
+	fetcher.Options = &config.CsvOptions{Delimiter: ";"}
+	fetcher.Provider = config.NewMemoryConfigRegistry(views, nil)
+
+	rows, err := fetcher.Fetch("v_staff", map[string]string{"month": "2025-01"})
+	if err != nil {
+		t.Fatalf("Fetch v_staff error: %v", err)
+	}
+	if len(rows) != 2 || rows[0]["name"] != "山田; 太郎" || rows[1]["name"] != "O'Brien" {
+		t.Fatalf("v_staff rows = %v", rows)
+	}
+
+	rows, err = fetcher.Fetch("v_sales", nil)
+	if err != nil {
+		t.Fatalf("Fetch v_sales error: %v", err)
+	}
+	if len(rows) != 2 || rows[0]["region"] != "EU" || rows[1]["region"] != "US" {
+		t.Fatalf("v_sales rows = %v, want EU then US keyed by region", rows)
+	}
+
+	if _, err := fetcher.Fetch("v_staff", nil); err == nil {
+		t.Fatal("expected error for path referencing a missing param")
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fibr-gen/config"
 	"fmt"
 	"sort"
+	"strings"
+	"sync"
 )
 
 // DataView represents a data view with label mapping capabilities.
@@ -11,8 +13,20 @@ type DataView struct {
 	Config       *config.DataViewConfig
 	Data         []map[string]interface{} // The actual data table (DataTable in C#)
 	LabelMapping map[string]string        // label Name -> Column Name
+
+	mu      sync.Mutex
+	indexes map[string]*labelIndex // Lookup indexes by their joined column names
+}
+
+// labelIndex is a hash index of the rows of a DataView on a set of columns.
+type labelIndex struct {
+	buckets map[string][]int // joined column values -> row positions, ascending
+	partial []int            // rows lacking an indexed column, matched by scanning
 }
 
+// indexKeySep joins column names and values into index keys.
+const indexKeySep = "\x00"
+
 // NewDataView creates a new DataView instance.
 func NewDataView(conf *config.DataViewConfig, data []map[string]interface{}) *DataView {
 	mapping := make(map[string]string)
@@ -26,7 +40,8 @@ func NewDataView(conf *config.DataViewConfig, data []map[string]interface{}) *Da
 	}
 }
 
-// GetDistinctLabelValues returns all unique values for a given label.
+// GetDistinctLabelValues returns all unique values for a given label, sorted
+// numerically or chronologically when they all are numbers or dates.
 func (v *DataView) GetDistinctLabelValues(label string) ([]string, error) {
 	colName, ok := v.LabelMapping[label]
 	if !ok {
@@ -56,8 +71,8 @@ func (v *DataView) GetDistinctLabelValues(label string) ([]string, error) {
 		}
 	}
 
-	// Sort for consistency
-	sort.Strings(result)
+	// Sort for consistency (numbers and dates by value)
+	sortLabelValues(result)
 	return result, nil
 }
 
@@ -93,6 +108,128 @@ func (v *DataView) Filter(params map[string]string) {
 	v.Data = filtered
 }
 
+// Lookup returns the rows matching params, with the semantics of Filter, without
+// modifying or copying the view. Rows are found through a hash index on the
+// label columns named by params, built on first use and reused by later
+// lookups, so resolving many cells (e.g. matrix intersections) costs O(1) each
+// instead of a scan of the view. The returned rows are shared with the view
+// and must not be modified.
+func (v *DataView) Lookup(params map[string]string) []map[string]interface{} {
+	values := make(map[string]string, len(params))
+	for paramKey, paramVal := range params {
+		colName, ok := v.LabelMapping[paramKey]
+		if !ok {
+			continue // Parameter not mapped to a label in this view, ignore
+		}
+		if prev, dup := values[colName]; dup && prev != paramVal {
+			// Two labels of one column with different values: only rows
+			// lacking the column can match, which the index does not track.
+			filtered := &DataView{Data: v.Data, LabelMapping: v.LabelMapping}
+			filtered.Filter(params)
+			return filtered.Data
+		}
+		values[colName] = paramVal
+	}
+	if len(values) == 0 {
+		return v.Data[:len(v.Data):len(v.Data)] // Appends by the caller reallocate
+	}
+
+	columns := make([]string, 0, len(values))
+	for col := range values {
+		columns = append(columns, col)
+	}
+	sort.Strings(columns)
+	key := make([]string, len(columns))
+	for i, col := range columns {
+		key[i] = values[col]
+	}
+
+	idx := v.index(columns)
+	positions := idx.buckets[strings.Join(key, indexKeySep)]
+	if len(idx.partial) > 0 {
+		positions = mergePositions(positions, matchPartial(v.Data, idx.partial, values))
+	}
+	if len(positions) == 0 {
+		return nil
+	}
+	rows := make([]map[string]interface{}, len(positions))
+	for i, pos := range positions {
+		rows[i] = v.Data[pos]
+	}
+	return rows
+}
+
+// index returns the index on columns (sorted), building it on first use.
+func (v *DataView) index(columns []string) *labelIndex {
+	name := strings.Join(columns, indexKeySep)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if idx, ok := v.indexes[name]; ok {
+		return idx
+	}
+
+	idx := &labelIndex{buckets: make(map[string][]int)}
+	key := make([]string, len(columns))
+rows:
+	for pos, row := range v.Data {
+		for i, col := range columns {
+			val, ok := row[col]
+			if !ok {
+				idx.partial = append(idx.partial, pos)
+				continue rows
+			}
+			key[i] = fmt.Sprintf("%v", val)
+		}
+		k := strings.Join(key, indexKeySep)
+		idx.buckets[k] = append(idx.buckets[k], pos)
+	}
+
+	if v.indexes == nil {
+		v.indexes = make(map[string]*labelIndex)
+	}
+	v.indexes[name] = idx
+	return idx
+}
+
+// matchPartial returns the positions of rows whose present columns match values.
+func matchPartial(data []map[string]interface{}, positions []int, values map[string]string) []int {
+	var matched []int
+	for _, pos := range positions {
+		match := true
+		for col, want := range values {
+			if val, ok := data[pos][col]; ok && fmt.Sprintf("%v", val) != want {
+				match = false
+				break
+			}
+		}
+		if match {
+			matched = append(matched, pos)
+		}
+	}
+	return matched
+}
+
+// mergePositions merges two ascending position lists, keeping row order.
+func mergePositions(a, b []int) []int {
+	if len(b) == 0 {
+		return a
+	}
+	merged := make([]int, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if a[i] < b[j] {
+			merged = append(merged, a[i])
+			i++
+		} else {
+			merged = append(merged, b[j])
+			j++
+		}
+	}
+	merged = append(merged, a[i:]...)
+	return append(merged, b[j:]...)
+}
+
 // GetRowCount returns the number of rows.
 func (v *DataView) GetRowCount() int {
 	return len(v.Data)
@@ -2,6 +2,7 @@ package core
 
 import (
 	"fibr-gen/config"
+	"fmt"
 	"reflect"
 	"testing"
 )
@@ -144,3 +145,118 @@ func TestDataView_Filter(t *testing.T) {
 		})
 	}
 }
+
+func TestDataView_Lookup(t *testing.T) {
+	conf := &config.DataViewConfig{
+		Name: "test_view",
+		Labels: []config.LabelConfig{
+			{Name: "label_dept", Column: "DEPT"},
+			{Name: "label_age", Column: "AGE"},
+			{Name: "label_dept_alias", Column: "DEPT"},
+		},
+	}
+	data := []map[string]interface{}{
+		{"DEPT": "D1", "AGE": 20, "ID": 1},
+		{"DEPT": "D1", "AGE": 30, "ID": 2},
+		{"AGE": 20, "ID": 3}, // No DEPT: matches any dept, like Filter
+		{"DEPT": "D2", "AGE": 20, "ID": 4},
+		{"DEPT": "D2", "AGE": 40, "ID": 5},
+	}
+	v := NewDataView(conf, data)
+
+	tests := []struct {
+		name   string
+		params map[string]string
+	}{
+		{"Single label", map[string]string{"label_dept": "D1"}},
+		{"Two labels", map[string]string{"label_dept": "D2", "label_age": "20"}},
+		{"Typed value", map[string]string{"label_age": "40"}},
+		{"No match", map[string]string{"label_dept": "D3"}},
+		{"Unmapped param", map[string]string{"label_dept": "D1", "unmapped_param": "xyz"}},
+		{"Empty params", map[string]string{}},
+		{"Same column, same value", map[string]string{"label_dept": "D1", "label_dept_alias": "D1"}},
+		{"Same column, different values", map[string]string{"label_dept": "D1", "label_dept_alias": "D2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := v.Copy()
+			want.Filter(tt.params)
+
+			// Twice: building the index, then reusing it
+			for i := 0; i < 2; i++ {
+				got := v.Lookup(tt.params)
+				if len(got) != len(want.Data) || (len(got) > 0 && !reflect.DeepEqual(got, want.Data)) {
+					t.Fatalf("Lookup() = %v, want %v", got, want.Data)
+				}
+			}
+		})
+	}
+
+	if len(v.Data) != len(data) {
+		t.Fatalf("Lookup modified the view: %d rows, want %d", len(v.Data), len(data))
+	}
+}
+
+// matrixView returns a view of employees x months rows, as used by the
+// intersection cells of a matrix block.
+func matrixView(employees, months int) *DataView {
+	conf := &config.DataViewConfig{
+		Name: "v_sales",
+		Labels: []config.LabelConfig{
+			{Name: "emp_id", Column: "EMP_ID"},
+			{Name: "month", Column: "MONTH"},
+			{Name: "amount", Column: "AMOUNT"},
+		},
+	}
+	data := make([]map[string]interface{}, 0, employees*months)
+	for e := range employees {
+		for m := range months {
+			data = append(data, map[string]interface{}{
+				"EMP_ID": fmt.Sprintf("E%03d", e),
+				"MONTH":  fmt.Sprintf("M%02d", m),
+				"AMOUNT": e * m,
+			})
+		}
+	}
+	return NewDataView(conf, data)
+}
+
+// matrixCellParams returns the params of every cell of the matrixView.
+func matrixCellParams(employees, months int) []map[string]string {
+	var cells []map[string]string
+	for e := range employees {
+		for m := range months {
+			cells = append(cells, map[string]string{
+				"emp_id": fmt.Sprintf("E%03d", e),
+				"month":  fmt.Sprintf("M%02d", m),
+			})
+		}
+	}
+	return cells
+}
+
+// BenchmarkDataView_MatrixCell_CopyFilter resolves one cell of a 500 x 36
+// matrix by copying and filtering the view, as GetBlockDataWithParams did.
+func BenchmarkDataView_MatrixCell_CopyFilter(b *testing.B) {
+	v := matrixView(500, 36)
+	cells := matrixCellParams(500, 36)
+	i := 0
+	for b.Loop() {
+		cell := v.Copy()
+		cell.Filter(cells[i%len(cells)])
+		i++
+	}
+}
+
+// BenchmarkDataView_MatrixCell_Lookup resolves one cell of a 500 x 36
+// matrix through the view's hash index.
+func BenchmarkDataView_MatrixCell_Lookup(b *testing.B) {
+	v := matrixView(500, 36)
+	cells := matrixCellParams(500, 36)
+	i := 0
+	for b.Loop() {
+		v.Lookup(cells[i%len(cells)])
+		i++
+	}
+}
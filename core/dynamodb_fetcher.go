@@ -2,7 +2,12 @@ package core
 
 import (
 	"context"
+	"errors"
+	"fibr-gen/config"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
@@ -10,15 +15,17 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
-// DynamoDBClient defines the interface needed for scanning.
+// DynamoDBClient defines the interface needed for scanning and querying.
 type DynamoDBClient interface {
 	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
 }
 
 // DynamoDBDataFetcher implements DataFetcher using AWS DynamoDB.
-// It maps viewName to a DynamoDB Table Name.
+// It maps a view to the DynamoDB table named by its Table, defaulting to the view name.
 type DynamoDBDataFetcher struct {
-	Client DynamoDBClient
+	Client   DynamoDBClient
+	Provider config.Provider // Optional: resolves view configs (Table, key schema)
 }
 
 // NewDynamoDBDataFetcher creates a new fetcher with the given AWS config.
@@ -28,62 +35,440 @@ func NewDynamoDBDataFetcher(cfg aws.Config) *DynamoDBDataFetcher {
 	}
 }
 
-// Fetch scans the DynamoDB table specified by viewName.
-// It applies simple equality filtering based on params if provided.
-// Note: Currently assumes all filter values are Strings.
+// Fetch reads the DynamoDB table of the view.
+// When the view declares a key schema and params cover its partition key, it
+// issues a Query with a KeyConditionExpression (adding the sort key if covered).
+// Otherwise it falls back to a Scan. Remaining params and the view's Filters
+// become a FilterExpression (a Query also uses range and prefix filters on the
+// sort key, and applies other conditions on the keys to the returned items).
+// A Scan runs in parallel segments when the view sets TotalSegments > 1.
+// Filter values are typed by the view's AttributeTypes, then by label types
+// (int / decimal -> N, bool -> BOOL), defaulting to S.
 func (f *DynamoDBDataFetcher) Fetch(viewName string, params map[string]string) ([]map[string]interface{}, error) {
-	// viewName corresponds to Table Name
-	tableName := viewName
-
-	var filterExpression *string
-	var expressionAttributeNames map[string]string
-	var expressionAttributeValues map[string]types.AttributeValue
-
-	if len(params) > 0 {
-		expr := ""
-		expressionAttributeNames = make(map[string]string)
-		expressionAttributeValues = make(map[string]types.AttributeValue)
-		idx := 0
-		for k, v := range params {
-			if idx > 0 {
-				expr += " AND "
-			}
-			// Use #k for name, :v for value to avoid reserved words conflicts
-			kName := fmt.Sprintf("#k%d", idx)
-			vName := fmt.Sprintf(":v%d", idx)
+	return f.FetchContext(context.Background(), viewName, params)
+}
+
+// FetchContext is Fetch with pagination and parallel segments bound to ctx.
+func (f *DynamoDBDataFetcher) FetchContext(ctx context.Context, viewName string, params map[string]string) ([]map[string]interface{}, error) {
+	conf, err := lookupViewConfig(f.Provider, viewName)
+	if err != nil {
+		return nil, err
+	}
+	tableName := physicalName(viewName, conf)
+	filters := pushdownParams(conf, params)
+	extra, err := viewFilters(conf, params)
+	if err != nil {
+		return nil, err
+	}
+	filters = append(filters, extra...)
 
-			expr += fmt.Sprintf("%s = %s", kName, vName)
-			expressionAttributeNames[kName] = k
-			// Assuming String value for filter.
-			// TODO: Support other types if needed (e.g. check if v is number)
-			expressionAttributeValues[vName] = &types.AttributeValueMemberS{Value: v}
-			idx++
+	var keySchema *config.DynamoDBViewConfig
+	if conf != nil {
+		keySchema = conf.DynamoDB
+	}
+	expr := newDynamoExpression(keySchema)
+	if keyFilters, rest, residual, ok := splitKeyFilters(keySchema, filters); ok {
+		items, err := f.query(ctx, tableName, keySchema.IndexName, expr, keyFilters, rest)
+		if err != nil {
+			return nil, err
 		}
-		filterExpression = aws.String(expr)
+		return applyFilters(items, residual), nil
 	}
+	return f.scan(ctx, tableName, expr, filters, keySchema)
+}
 
+func (f *DynamoDBDataFetcher) scan(ctx context.Context, tableName string, expr *dynamoExpression, filters []columnFilter, opts *config.DynamoDBViewConfig) ([]map[string]interface{}, error) {
+	filterExpression, err := expr.conditions(filters)
+	if err != nil {
+		return nil, err
+	}
 	input := &dynamodb.ScanInput{
-		TableName:                 aws.String(tableName),
-		FilterExpression:          filterExpression,
-		ExpressionAttributeNames:  expressionAttributeNames,
-		ExpressionAttributeValues: expressionAttributeValues,
+		TableName:        aws.String(tableName),
+		FilterExpression: filterExpression,
+	}
+	input.ExpressionAttributeNames, input.ExpressionAttributeValues = expr.attributes()
+
+	if opts != nil && opts.TotalSegments > 1 {
+		concurrency := opts.ScanConcurrency
+		if concurrency <= 0 {
+			concurrency = defaultScanConcurrency
+		}
+		return f.parallelScan(ctx, input, opts.TotalSegments, concurrency)
 	}
+	return f.scanPages(ctx, input)
+}
+
+// defaultScanConcurrency bounds parallel Scan workers when the view does not set ScanConcurrency.
+const defaultScanConcurrency = 4
+
+// parallelScan reads totalSegments Scan segments with at most concurrency in flight.
+// Results are merged in segment order; the first failure cancels the remaining segments.
+func (f *DynamoDBDataFetcher) parallelScan(ctx context.Context, input *dynamodb.ScanInput, totalSegments, concurrency int) ([]map[string]interface{}, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
+	results := make([][]map[string]interface{}, totalSegments)
+	errs := make([]error, totalSegments)
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for segment := 0; segment < totalSegments; segment++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(segment int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			segInput := *input
+			segInput.Segment = aws.Int32(int32(segment))
+			segInput.TotalSegments = aws.Int32(int32(totalSegments))
+			results[segment], errs[segment] = f.scanPages(ctx, &segInput)
+			if errs[segment] != nil {
+				cancel()
+			}
+		}(segment)
+	}
+	wg.Wait()
+
+	// Report the root cause rather than the cancellations it triggered.
+	var firstErr error
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var items []map[string]interface{}
+	for _, segmentItems := range results {
+		items = append(items, segmentItems...)
+	}
+	return items, nil
+}
+
+func (f *DynamoDBDataFetcher) scanPages(ctx context.Context, input *dynamodb.ScanInput) ([]map[string]interface{}, error) {
 	paginator := dynamodb.NewScanPaginator(f.Client, input)
 	var items []map[string]interface{}
 
 	for paginator.HasMorePages() {
-		page, err := paginator.NextPage(context.TODO())
+		page, err := paginator.NextPage(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan table %s: %w", tableName, err)
+			return nil, fmt.Errorf("failed to scan table %s: %w", aws.ToString(input.TableName), err)
 		}
+		if items, err = appendItems(items, page.Items); err != nil {
+			return nil, err
+		}
+	}
+
+	return items, nil
+}
+
+func (f *DynamoDBDataFetcher) query(ctx context.Context, tableName, indexName string, expr *dynamoExpression, keyFilters, filters []columnFilter) ([]map[string]interface{}, error) {
+	keyExpression, err := expr.conditions(keyFilters)
+	if err != nil {
+		return nil, err
+	}
+	filterExpression, err := expr.conditions(filters)
+	if err != nil {
+		return nil, err
+	}
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		KeyConditionExpression: keyExpression,
+		FilterExpression:       filterExpression,
+	}
+	if indexName != "" {
+		input.IndexName = aws.String(indexName)
+	}
+	input.ExpressionAttributeNames, input.ExpressionAttributeValues = expr.attributes()
 
-		var pageItems []map[string]interface{}
-		if err := attributevalue.UnmarshalListOfMaps(page.Items, &pageItems); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal items: %w", err)
+	paginator := dynamodb.NewQueryPaginator(f.Client, input)
+	var items []map[string]interface{}
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query table %s: %w", tableName, err)
+		}
+		if items, err = appendItems(items, page.Items); err != nil {
+			return nil, err
 		}
-		items = append(items, pageItems...)
 	}
 
 	return items, nil
 }
+
+// splitKeyFilters builds the key condition of a Query from the filters: an
+// equality on the partition key, and on the sort key an equality, a prefix or
+// a range (a lower and an upper bound become one BETWEEN, inclusive). The
+// other filters are returned in rest, except those on key attributes: a Query
+// FilterExpression cannot name them, so they are returned in residual, with
+// the bounds merged into a BETWEEN, to be applied to the queried items.
+// It reports false when the partition key is not covered.
+func splitKeyFilters(keySchema *config.DynamoDBViewConfig, filters []columnFilter) (keyFilters, rest, residual []columnFilter, ok bool) {
+	if keySchema == nil || keySchema.PartitionKey == "" {
+		return nil, filters, nil, false
+	}
+	var partition, sortEq, lower, upper *columnFilter
+	for i := range filters {
+		filter := &filters[i]
+		op := filter.op()
+		switch {
+		case filter.Column == keySchema.PartitionKey:
+			if partition == nil && op == config.FilterOpEq {
+				partition = filter
+			} else {
+				residual = append(residual, *filter)
+			}
+		case keySchema.SortKey != "" && filter.Column == keySchema.SortKey:
+			switch {
+			case (op == config.FilterOpEq || op == config.FilterOpPrefix) && sortEq == nil:
+				sortEq = filter
+			case (op == config.FilterOpGt || op == config.FilterOpGe) && lower == nil:
+				lower = filter
+			case (op == config.FilterOpLt || op == config.FilterOpLe) && upper == nil:
+				upper = filter
+			default:
+				residual = append(residual, *filter)
+			}
+		default:
+			rest = append(rest, *filter)
+		}
+	}
+	if partition == nil {
+		return nil, filters, nil, false
+	}
+	keyFilters = append(keyFilters, *partition)
+	switch {
+	case sortEq != nil:
+		keyFilters = append(keyFilters, *sortEq)
+		for _, bound := range []*columnFilter{lower, upper} {
+			if bound != nil {
+				residual = append(residual, *bound)
+			}
+		}
+	case lower != nil && upper != nil:
+		keyFilters = append(keyFilters, columnFilter{
+			Column: keySchema.SortKey,
+			Op:     filterOpBetween,
+			Values: []string{lower.Value, upper.Value},
+			Type:   lower.Type,
+		})
+		residual = append(residual, *lower, *upper)
+	case lower != nil:
+		keyFilters = append(keyFilters, *lower)
+	case upper != nil:
+		keyFilters = append(keyFilters, *upper)
+	}
+	return keyFilters, rest, residual, true
+}
+
+// filterOpBetween is the inclusive range of a sort key condition, with the
+// bounds in Values. Filter configs cannot use it.
+const filterOpBetween config.FilterOp = "between"
+
+// dynamoExpression accumulates placeholder names and values shared by the
+// key condition and filter expressions of one request.
+type dynamoExpression struct {
+	names     map[string]string
+	values    map[string]types.AttributeValue
+	attrTypes map[string]string // attribute -> declared DynamoDB type
+}
+
+func newDynamoExpression(keySchema *config.DynamoDBViewConfig) *dynamoExpression {
+	e := &dynamoExpression{
+		names:  make(map[string]string),
+		values: make(map[string]types.AttributeValue),
+	}
+	if keySchema != nil {
+		e.attrTypes = keySchema.AttributeTypes
+	}
+	return e
+}
+
+// conditions returns the AND of conditions for filters, or nil if there are none.
+// Scalar attributes support every filter operator; set and list attributes
+// only membership (equality or contains).
+func (e *dynamoExpression) conditions(filters []columnFilter) (*string, error) {
+	if len(filters) == 0 {
+		return nil, nil
+	}
+	parts := make([]string, 0, len(filters))
+	for _, filter := range filters {
+		part, err := e.condition(filter)
+		if err != nil {
+			return nil, fmt.Errorf("filter on attribute '%s': %w", filter.Column, err)
+		}
+		parts = append(parts, part)
+	}
+	return aws.String(strings.Join(parts, " AND ")), nil
+}
+
+// dynamoOperators maps comparison filter operators to DynamoDB expressions.
+var dynamoOperators = map[config.FilterOp]string{
+	config.FilterOpEq: "=",
+	config.FilterOpNe: "<>",
+	config.FilterOpLt: "<",
+	config.FilterOpLe: "<=",
+	config.FilterOpGt: ">",
+	config.FilterOpGe: ">=",
+}
+
+func (e *dynamoExpression) condition(filter columnFilter) (string, error) {
+	// Use #k for name, :v for value to avoid reserved words conflicts
+	idx := len(e.names)
+	kName := fmt.Sprintf("#k%d", idx)
+	vName := fmt.Sprintf(":v%d", idx)
+	e.names[kName] = filter.Column
+
+	op := filter.op()
+	attrType := e.attributeType(filter)
+	switch op {
+	case config.FilterOpNull:
+		e.values[vName] = &types.AttributeValueMemberS{Value: "NULL"}
+		return fmt.Sprintf("(attribute_not_exists(%s) OR attribute_type(%s, %s))", kName, kName, vName), nil
+	case config.FilterOpNotNull:
+		e.values[vName] = &types.AttributeValueMemberS{Value: "NULL"}
+		return fmt.Sprintf("(attribute_exists(%s) AND NOT attribute_type(%s, %s))", kName, kName, vName), nil
+	case config.FilterOpPrefix:
+		if attrType != "S" {
+			return "", fmt.Errorf("prefix requires a string attribute, not %s", attrType)
+		}
+		e.values[vName] = &types.AttributeValueMemberS{Value: filter.Value}
+		return fmt.Sprintf("begins_with(%s, %s)", kName, vName), nil
+	}
+
+	switch attrType {
+	case "SS", "NS", "L":
+		if op != config.FilterOpEq && op != config.FilterOpContains {
+			return "", fmt.Errorf("operator '%s' is not supported on %s attributes", op, attrType)
+		}
+		elemType := labelAttributeType(filter.Type)
+		if attrType == "SS" {
+			elemType = "S"
+		} else if attrType == "NS" {
+			elemType = "N"
+		}
+		value, err := dynamoAttributeValue(elemType, filter.Value)
+		if err != nil {
+			return "", err
+		}
+		e.values[vName] = value
+		return fmt.Sprintf("contains(%s, %s)", kName, vName), nil
+	}
+
+	switch op {
+	case config.FilterOpContains:
+		if attrType != "S" {
+			return "", fmt.Errorf("contains requires a string attribute, not %s", attrType)
+		}
+		e.values[vName] = &types.AttributeValueMemberS{Value: filter.Value}
+		return fmt.Sprintf("contains(%s, %s)", kName, vName), nil
+	case filterOpBetween:
+		names := [2]string{vName + "_lo", vName + "_hi"}
+		for i, name := range names {
+			value, err := dynamoAttributeValue(attrType, filter.Values[i])
+			if err != nil {
+				return "", err
+			}
+			e.values[name] = value
+		}
+		return fmt.Sprintf("%s BETWEEN %s AND %s", kName, names[0], names[1]), nil
+	case config.FilterOpIn:
+		if len(filter.Values) == 0 {
+			return fmt.Sprintf("(attribute_exists(%s) AND attribute_not_exists(%s))", kName, kName), nil // matches nothing
+		}
+		names := make([]string, len(filter.Values))
+		for i, item := range filter.Values {
+			value, err := dynamoAttributeValue(attrType, item)
+			if err != nil {
+				return "", err
+			}
+			names[i] = fmt.Sprintf("%s_%d", vName, i)
+			e.values[names[i]] = value
+		}
+		return fmt.Sprintf("%s IN (%s)", kName, strings.Join(names, ", ")), nil
+	default:
+		value, err := dynamoAttributeValue(attrType, filter.Value)
+		if err != nil {
+			return "", err
+		}
+		e.values[vName] = value
+		return fmt.Sprintf("%s %s %s", kName, dynamoOperators[op], vName), nil
+	}
+}
+
+// attributeType returns the declared DynamoDB type of the filtered attribute,
+// falling back to the type implied by the label.
+func (e *dynamoExpression) attributeType(filter columnFilter) string {
+	if t, ok := e.attrTypes[filter.Column]; ok {
+		return t
+	}
+	return labelAttributeType(filter.Type)
+}
+
+// labelAttributeType maps a label type to the DynamoDB scalar type of its values.
+func labelAttributeType(t config.LabelType) string {
+	switch t {
+	case config.LabelTypeInt, config.LabelTypeDecimal:
+		return "N"
+	case config.LabelTypeBool:
+		return "BOOL"
+	default:
+		return "S"
+	}
+}
+
+// dynamoAttributeValue converts a parameter value to a scalar attribute value.
+func dynamoAttributeValue(attrType, value string) (types.AttributeValue, error) {
+	switch attrType {
+	case "N":
+		if _, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err != nil {
+			return nil, fmt.Errorf("value %q is not a number", value)
+		}
+		return &types.AttributeValueMemberN{Value: strings.TrimSpace(value)}, nil
+	case "BOOL":
+		b, err := coerceBool(value)
+		if err != nil {
+			return nil, err
+		}
+		return &types.AttributeValueMemberBOOL{Value: b.(bool)}, nil
+	default:
+		return &types.AttributeValueMemberS{Value: value}, nil
+	}
+}
+
+// attributes returns the expression attribute maps, each nil when empty as
+// DynamoDB rejects empty maps (an empty "in" list names an attribute but
+// binds no value).
+func (e *dynamoExpression) attributes() (names map[string]string, values map[string]types.AttributeValue) {
+	if len(e.names) > 0 {
+		names = e.names
+	}
+	if len(e.values) > 0 {
+		values = e.values
+	}
+	return names, values
+}
+
+func appendItems(items []map[string]interface{}, page []map[string]types.AttributeValue) ([]map[string]interface{}, error) {
+	var pageItems []map[string]interface{}
+	if err := attributevalue.UnmarshalListOfMaps(page, &pageItems); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal items: %w", err)
+	}
+	return append(items, pageItems...), nil
+}
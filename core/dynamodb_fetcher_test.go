@@ -2,20 +2,32 @@ package core
 
 import (
 	"context"
+	"errors"
+	"fibr-gen/config"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
 type MockDynamoDBClient struct {
-	ScanFunc func(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+	ScanFunc  func(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+	QueryFunc func(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
 }
 
 func (m *MockDynamoDBClient) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
 	return m.ScanFunc(ctx, params, optFns...)
 }
 
+func (m *MockDynamoDBClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	return m.QueryFunc(ctx, params, optFns...)
+}
+
 func TestDynamoDBDataFetcher_Fetch(t *testing.T) {
 	mockClient := &MockDynamoDBClient{
 		ScanFunc: func(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
@@ -66,3 +78,383 @@ func TestDynamoDBDataFetcher_Fetch(t *testing.T) {
 		t.Errorf("name = %v, want Test Name", results[0]["name"])
 	}
 }
+
+func TestDynamoDBDataFetcher_FetchUsesViewTable(t *testing.T) {
+	var tableName string
+	mockClient := &MockDynamoDBClient{
+		ScanFunc: func(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+			tableName = *params.TableName
+			return &dynamodb.ScanOutput{}, nil
+		},
+	}
+
+	views := map[string]*config.DataViewConfig{
+		"monthly_sales_eu": {Name: "monthly_sales_eu", Table: "prod-sales"},
+	}
+	fetcher := &DynamoDBDataFetcher{Client: mockClient, Provider: config.NewMemoryConfigRegistry(views, nil)}
+	if _, err := fetcher.Fetch("monthly_sales_eu", nil); err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	if tableName != "prod-sales" {
+		t.Errorf("TableName = %s, want prod-sales", tableName)
+	}
+}
+
+func TestDynamoDBDataFetcher_FetchQueriesByKey(t *testing.T) {
+	views := map[string]*config.DataViewConfig{
+		"v_sales": {
+			Name:  "v_sales",
+			Table: "prod-sales",
+			Labels: []config.LabelConfig{
+				{Name: "store", Column: "store_id"},
+				{Name: "month", Column: "month"},
+				{Name: "region", Column: "region"},
+			},
+			DynamoDB: &config.DynamoDBViewConfig{PartitionKey: "store_id", SortKey: "month", IndexName: "store-month-index"},
+		},
+	}
+	mockClient := &MockDynamoDBClient{
+		ScanFunc: func(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+			if params.FilterExpression == nil || *params.FilterExpression != "#k0 = :v0" {
+				t.Errorf("Scan FilterExpression = %v, want #k0 = :v0", params.FilterExpression)
+			}
+			return &dynamodb.ScanOutput{}, nil
+		},
+		QueryFunc: func(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+			if *params.IndexName != "store-month-index" {
+				t.Errorf("IndexName = %v, want store-month-index", *params.IndexName)
+			}
+			if *params.KeyConditionExpression != "#k0 = :v0 AND #k1 = :v1" {
+				t.Errorf("KeyConditionExpression = %s", *params.KeyConditionExpression)
+			}
+			if params.ExpressionAttributeNames["#k0"] != "store_id" || params.ExpressionAttributeNames["#k1"] != "month" {
+				t.Errorf("key names = %v, want store_id and month", params.ExpressionAttributeNames)
+			}
+			if *params.FilterExpression != "#k2 = :v2" || params.ExpressionAttributeNames["#k2"] != "region" {
+				t.Errorf("FilterExpression = %s with names %v, want region filter", *params.FilterExpression, params.ExpressionAttributeNames)
+			}
+			return &dynamodb.QueryOutput{
+				Items: []map[string]types.AttributeValue{
+					{"store_id": &types.AttributeValueMemberS{Value: "S1"}},
+				},
+			}, nil
+		},
+	}
+	fetcher := &DynamoDBDataFetcher{Client: mockClient, Provider: config.NewMemoryConfigRegistry(views, nil)}
+
+	rows, err := fetcher.Fetch("v_sales", map[string]string{"store": "S1", "month": "2025-01", "region": "EU", "env": "dev"})
+	if err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["store_id"] != "S1" {
+		t.Fatalf("rows = %v, want one S1 item", rows)
+	}
+
+	// Partition key not covered: fall back to Scan.
+	if _, err := fetcher.Fetch("v_sales", map[string]string{"region": "EU"}); err != nil {
+		t.Fatalf("Fetch (scan) error: %v", err)
+	}
+}
+
+func TestDynamoDBDataFetcher_FetchTypedFilters(t *testing.T) {
+	views := map[string]*config.DataViewConfig{
+		"v_orders": {
+			Name: "v_orders",
+			Labels: []config.LabelConfig{
+				{Name: "year", Column: "year", Type: config.LabelTypeInt},
+				{Name: "paid", Column: "paid", Type: config.LabelTypeBool},
+				{Name: "tag", Column: "tags"},
+				{Name: "code", Column: "code", Type: config.LabelTypeInt},
+			},
+			DynamoDB: &config.DynamoDBViewConfig{
+				AttributeTypes: map[string]string{"tags": "SS", "code": "S"},
+			},
+		},
+	}
+	var got *dynamodb.ScanInput
+	mockClient := &MockDynamoDBClient{
+		ScanFunc: func(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+			got = params
+			return &dynamodb.ScanOutput{}, nil
+		},
+	}
+	fetcher := &DynamoDBDataFetcher{Client: mockClient, Provider: config.NewMemoryConfigRegistry(views, nil)}
+
+	if _, err := fetcher.Fetch("v_orders", map[string]string{"year": "2025", "paid": "true", "tag": "vip", "code": "007"}); err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	// Filters are ordered by column: code, paid, tags, year.
+	if want := "#k0 = :v0 AND #k1 = :v1 AND contains(#k2, :v2) AND #k3 = :v3"; *got.FilterExpression != want {
+		t.Errorf("FilterExpression = %s, want %s", *got.FilterExpression, want)
+	}
+	wantValues := map[string]types.AttributeValue{
+		":v0": &types.AttributeValueMemberS{Value: "007"},
+		":v1": &types.AttributeValueMemberBOOL{Value: true},
+		":v2": &types.AttributeValueMemberS{Value: "vip"},
+		":v3": &types.AttributeValueMemberN{Value: "2025"},
+	}
+	if !reflect.DeepEqual(got.ExpressionAttributeValues, wantValues) {
+		t.Errorf("ExpressionAttributeValues = %#v, want %#v", got.ExpressionAttributeValues, wantValues)
+	}
+
+	if _, err := fetcher.Fetch("v_orders", map[string]string{"year": "last"}); err == nil {
+		t.Fatal("expected error for non-numeric value of a numeric attribute")
+	}
+}
+
+func TestDynamoDBDataFetcher_ParallelScan(t *testing.T) {
+	views := map[string]*config.DataViewConfig{
+		"v_big": {Name: "v_big", DynamoDB: &config.DynamoDBViewConfig{TotalSegments: 4, ScanConcurrency: 2}},
+	}
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	mockClient := &MockDynamoDBClient{
+		ScanFunc: func(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+			mu.Lock()
+			inFlight++
+			maxInFlight = max(maxInFlight, inFlight)
+			mu.Unlock()
+			defer func() {
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+			}()
+
+			if *params.TotalSegments != 4 {
+				t.Errorf("TotalSegments = %d, want 4", *params.TotalSegments)
+			}
+			segment := *params.Segment
+			// Later segments finish first; the merge must still follow segment order.
+			time.Sleep(time.Duration(4-segment) * 5 * time.Millisecond)
+			return &dynamodb.ScanOutput{
+				Items: []map[string]types.AttributeValue{
+					{"seg": &types.AttributeValueMemberN{Value: strconv.Itoa(int(segment))}},
+				},
+			}, nil
+		},
+	}
+	fetcher := &DynamoDBDataFetcher{Client: mockClient, Provider: config.NewMemoryConfigRegistry(views, nil)}
+
+	rows, err := fetcher.Fetch("v_big", nil)
+	if err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("got %d rows, want 4", len(rows))
+	}
+	for i, row := range rows {
+		if row["seg"] != float64(i) {
+			t.Errorf("row %d seg = %v, want %d", i, row["seg"], i)
+		}
+	}
+	if maxInFlight > 2 {
+		t.Errorf("max concurrent segments = %d, want <= 2", maxInFlight)
+	}
+}
+
+func TestDynamoDBDataFetcher_ParallelScanCancelsOnError(t *testing.T) {
+	views := map[string]*config.DataViewConfig{
+		"v_big": {Name: "v_big", DynamoDB: &config.DynamoDBViewConfig{TotalSegments: 3, ScanConcurrency: 3}},
+	}
+	boom := errors.New("throttled")
+	mockClient := &MockDynamoDBClient{
+		ScanFunc: func(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+			if *params.Segment == 1 {
+				return nil, boom
+			}
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(5 * time.Second):
+				t.Error("segment was not cancelled")
+				return &dynamodb.ScanOutput{}, nil
+			}
+		},
+	}
+	fetcher := &DynamoDBDataFetcher{Client: mockClient, Provider: config.NewMemoryConfigRegistry(views, nil)}
+
+	_, err := fetcher.Fetch("v_big", nil)
+	if !errors.Is(err, boom) {
+		t.Fatalf("Fetch error = %v, want %v", err, boom)
+	}
+}
+
+func TestDynamoDBDataFetcher_FetchViewFilters(t *testing.T) {
+	views := map[string]*config.DataViewConfig{
+		"v_sales": {
+			Name: "v_sales",
+			Labels: []config.LabelConfig{
+				{Name: "store", Column: "store_id"},
+				{Name: "month", Column: "month"},
+				{Name: "amount", Column: "amount", Type: config.LabelTypeDecimal},
+				{Name: "region", Column: "region"},
+			},
+			Filters: []config.FilterConfig{
+				{Label: "month", Op: config.FilterOpPrefix, Value: "${year}-"},
+				{Label: "amount", Op: config.FilterOpGt, Value: "100"},
+				{Label: "region", Op: config.FilterOpIn, Value: "EU,US"},
+				{Label: "region", Op: config.FilterOpNotNull},
+			},
+			DynamoDB: &config.DynamoDBViewConfig{PartitionKey: "store_id", SortKey: "month"},
+		},
+	}
+	var got *dynamodb.QueryInput
+	mockClient := &MockDynamoDBClient{
+		QueryFunc: func(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+			got = params
+			return &dynamodb.QueryOutput{}, nil
+		},
+	}
+	fetcher := &DynamoDBDataFetcher{Client: mockClient, Provider: config.NewMemoryConfigRegistry(views, nil)}
+
+	if _, err := fetcher.Fetch("v_sales", map[string]string{"store": "S1", "year": "2025"}); err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	if want := "#k0 = :v0 AND begins_with(#k1, :v1)"; *got.KeyConditionExpression != want {
+		t.Errorf("KeyConditionExpression = %s, want %s", *got.KeyConditionExpression, want)
+	}
+	if want := "#k2 > :v2 AND #k3 IN (:v3_0, :v3_1) AND (attribute_exists(#k4) AND NOT attribute_type(#k4, :v4))"; *got.FilterExpression != want {
+		t.Errorf("FilterExpression = %s, want %s", *got.FilterExpression, want)
+	}
+	wantValues := map[string]types.AttributeValue{
+		":v0":   &types.AttributeValueMemberS{Value: "S1"},
+		":v1":   &types.AttributeValueMemberS{Value: "2025-"},
+		":v2":   &types.AttributeValueMemberN{Value: "100"},
+		":v3_0": &types.AttributeValueMemberS{Value: "EU"},
+		":v3_1": &types.AttributeValueMemberS{Value: "US"},
+		":v4":   &types.AttributeValueMemberS{Value: "NULL"},
+	}
+	if !reflect.DeepEqual(got.ExpressionAttributeValues, wantValues) {
+		t.Errorf("ExpressionAttributeValues = %#v, want %#v", got.ExpressionAttributeValues, wantValues)
+	}
+}
+
+func TestDynamoDBDataFetcher_QueryKeepsKeysOutOfFilterExpression(t *testing.T) {
+	views := map[string]*config.DataViewConfig{
+		"v_sales": {
+			Name: "v_sales",
+			Labels: []config.LabelConfig{
+				{Name: "store", Column: "store_id"},
+				{Name: "date", Column: "date"},
+				{Name: "region", Column: "region"},
+			},
+			Filters: []config.FilterConfig{
+				{Label: "date", Op: config.FilterOpGe, Value: "${start}"},
+				{Label: "date", Op: config.FilterOpLt, Value: "${end}"},
+				{Label: "date", Op: config.FilterOpNe, Value: "2025-01-15"},
+				{Label: "store", Op: config.FilterOpNe, Value: "S9"},
+				{Label: "region", Op: config.FilterOpNe, Value: "EU"},
+			},
+			DynamoDB: &config.DynamoDBViewConfig{PartitionKey: "store_id", SortKey: "date"},
+		},
+	}
+	var got *dynamodb.QueryInput
+	mockClient := &MockDynamoDBClient{
+		QueryFunc: func(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+			got = params
+			return &dynamodb.QueryOutput{}, nil
+		},
+	}
+	fetcher := &DynamoDBDataFetcher{Client: mockClient, Provider: config.NewMemoryConfigRegistry(views, nil)}
+
+	params := map[string]string{"store": "S1", "start": "2025-01-01", "end": "2025-02-01"}
+	if _, err := fetcher.Fetch("v_sales", params); err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	if want := "#k0 = :v0 AND #k1 BETWEEN :v1_lo AND :v1_hi"; *got.KeyConditionExpression != want {
+		t.Errorf("KeyConditionExpression = %s, want %s", *got.KeyConditionExpression, want)
+	}
+	if got.ExpressionAttributeNames["#k1"] != "date" {
+		t.Errorf("#k1 = %s, want date", got.ExpressionAttributeNames["#k1"])
+	}
+	wantBounds := map[string]types.AttributeValue{
+		":v1_lo": &types.AttributeValueMemberS{Value: "2025-01-01"},
+		":v1_hi": &types.AttributeValueMemberS{Value: "2025-02-01"},
+	}
+	for name, want := range wantBounds {
+		if !reflect.DeepEqual(got.ExpressionAttributeValues[name], want) {
+			t.Errorf("%s = %#v, want %#v", name, got.ExpressionAttributeValues[name], want)
+		}
+	}
+
+	// The FilterExpression must never name a key attribute.
+	if got.FilterExpression == nil {
+		t.Fatal("FilterExpression is nil, want the region filter")
+	}
+	for placeholder, attr := range got.ExpressionAttributeNames {
+		if (attr == "store_id" || attr == "date") && strings.Contains(*got.FilterExpression, placeholder) {
+			t.Errorf("FilterExpression %s names key attribute %s", *got.FilterExpression, attr)
+		}
+	}
+	if want := "#k2 <> :v2"; *got.FilterExpression != want || got.ExpressionAttributeNames["#k2"] != "region" {
+		t.Errorf("FilterExpression = %s with names %v, want %s on region", *got.FilterExpression, got.ExpressionAttributeNames, want)
+	}
+}
+
+func TestDynamoDBDataFetcher_QueryFiltersKeyConditionsInMemory(t *testing.T) {
+	views := map[string]*config.DataViewConfig{
+		"v_sales": {
+			Name: "v_sales",
+			Labels: []config.LabelConfig{
+				{Name: "store", Column: "store_id"},
+				{Name: "date", Column: "date"},
+			},
+			Filters: []config.FilterConfig{
+				{Label: "date", Op: config.FilterOpGt, Value: "${start}"},
+				{Label: "date", Op: config.FilterOpLt, Value: "${end}"},
+				{Label: "date", Op: config.FilterOpNe, Value: "2025-01-20"},
+			},
+			DynamoDB: &config.DynamoDBViewConfig{PartitionKey: "store_id", SortKey: "date"},
+		},
+	}
+	// The BETWEEN key condition is inclusive: the table returns the bounds too.
+	mockClient := &MockDynamoDBClient{
+		QueryFunc: func(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+			var items []map[string]types.AttributeValue
+			for _, date := range []string{"2025-01-01", "2025-01-10", "2025-01-20", "2025-02-01"} {
+				items = append(items, map[string]types.AttributeValue{
+					"store_id": &types.AttributeValueMemberS{Value: "S1"},
+					"date":     &types.AttributeValueMemberS{Value: date},
+				})
+			}
+			return &dynamodb.QueryOutput{Items: items}, nil
+		},
+	}
+	fetcher := &DynamoDBDataFetcher{Client: mockClient, Provider: config.NewMemoryConfigRegistry(views, nil)}
+
+	rows, err := fetcher.Fetch("v_sales", map[string]string{"store": "S1", "start": "2025-01-01", "end": "2025-02-01"})
+	if err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["date"] != "2025-01-10" {
+		t.Errorf("rows = %v, want only 2025-01-10", rows)
+	}
+}
+
+func TestDynamoDBDataFetcher_EmptyInListSendsNoValues(t *testing.T) {
+	views := map[string]*config.DataViewConfig{
+		"v_sales": {
+			Name:    "v_sales",
+			Labels:  []config.LabelConfig{{Name: "region", Column: "region"}},
+			Filters: []config.FilterConfig{{Label: "region", Op: config.FilterOpIn, Value: "${regions}"}},
+		},
+	}
+	var got *dynamodb.ScanInput
+	mockClient := &MockDynamoDBClient{
+		ScanFunc: func(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+			got = params
+			return &dynamodb.ScanOutput{}, nil
+		},
+	}
+	fetcher := &DynamoDBDataFetcher{Client: mockClient, Provider: config.NewMemoryConfigRegistry(views, nil)}
+
+	if _, err := fetcher.Fetch("v_sales", map[string]string{"regions": ""}); err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	if len(got.ExpressionAttributeNames) != 1 {
+		t.Errorf("ExpressionAttributeNames = %v, want the region name", got.ExpressionAttributeNames)
+	}
+	if got.ExpressionAttributeValues != nil {
+		t.Errorf("ExpressionAttributeValues = %v, want nil", got.ExpressionAttributeValues)
+	}
+}
@@ -8,8 +8,12 @@ type ExcelFile interface {
 	CopySheet(from, to int) error
 	DeleteSheet(name string)
 	GetCellStyle(sheet, cell string) (int, error)
+	GetStyle(styleID int) (*excelize.Style, error)
+	NewStyle(style *excelize.Style) (int, error)
 	GetCellValue(sheet, cell string) (string, error)
+	GetCellFormula(sheet, cell string) (string, error)
 	GetSheetDimension(sheet string) (string, error)
+	SetSheetDimension(sheet, rangeRef string) error
 	GetSheetIndex(name string) (int, error)
 	InsertCols(sheet, col string, columns int) error
 	InsertRows(sheet string, row, rows int) error
@@ -19,7 +23,13 @@ type ExcelFile interface {
 	SaveAs(name string) error
 	SetCellStyle(sheet, hcell, vcell string, styleID int) error
 	SetCellValue(sheet, cell string, value interface{}) error
+	SetCellFormula(sheet, cell, formula string) error
 	GetSheetList() []string
+	SetSheetName(source, target string) error
+	GetHeaderFooter(sheet string) (*excelize.HeaderFooterOptions, error)
+	SetHeaderFooter(sheet string, opts *excelize.HeaderFooterOptions) error
+	GetDocProps() (*excelize.DocProperties, error)
+	SetDocProps(props *excelize.DocProperties) error
 	SetActiveSheet(index int)
 	SetSelection(sheetName, cell string) error
 }
@@ -52,14 +62,30 @@ func (e *ExcelizeFile) GetCellStyle(sheet, cell string) (int, error) {
 	return e.file.GetCellStyle(sheet, cell)
 }
 
+func (e *ExcelizeFile) GetStyle(styleID int) (*excelize.Style, error) {
+	return e.file.GetStyle(styleID)
+}
+
+func (e *ExcelizeFile) NewStyle(style *excelize.Style) (int, error) {
+	return e.file.NewStyle(style)
+}
+
 func (e *ExcelizeFile) GetCellValue(sheet, cell string) (string, error) {
 	return e.file.GetCellValue(sheet, cell)
 }
 
+func (e *ExcelizeFile) GetCellFormula(sheet, cell string) (string, error) {
+	return e.file.GetCellFormula(sheet, cell)
+}
+
 func (e *ExcelizeFile) GetSheetDimension(sheet string) (string, error) {
 	return e.file.GetSheetDimension(sheet)
 }
 
+func (e *ExcelizeFile) SetSheetDimension(sheet, rangeRef string) error {
+	return e.file.SetSheetDimension(sheet, rangeRef)
+}
+
 func (e *ExcelizeFile) GetSheetIndex(name string) (int, error) {
 	return e.file.GetSheetIndex(name)
 }
@@ -96,10 +122,34 @@ func (e *ExcelizeFile) SetCellValue(sheet, cell string, value interface{}) error
 	return e.file.SetCellValue(sheet, cell, value)
 }
 
+func (e *ExcelizeFile) SetCellFormula(sheet, cell, formula string) error {
+	return e.file.SetCellFormula(sheet, cell, formula)
+}
+
 func (e *ExcelizeFile) GetSheetList() []string {
 	return e.file.GetSheetList()
 }
 
+func (e *ExcelizeFile) SetSheetName(source, target string) error {
+	return e.file.SetSheetName(source, target)
+}
+
+func (e *ExcelizeFile) GetHeaderFooter(sheet string) (*excelize.HeaderFooterOptions, error) {
+	return e.file.GetHeaderFooter(sheet)
+}
+
+func (e *ExcelizeFile) SetHeaderFooter(sheet string, opts *excelize.HeaderFooterOptions) error {
+	return e.file.SetHeaderFooter(sheet, opts)
+}
+
+func (e *ExcelizeFile) GetDocProps() (*excelize.DocProperties, error) {
+	return e.file.GetDocProps()
+}
+
+func (e *ExcelizeFile) SetDocProps(props *excelize.DocProperties) error {
+	return e.file.SetDocProps(props)
+}
+
 func (e *ExcelizeFile) SetActiveSheet(index int) {
 	e.file.SetActiveSheet(index)
 }
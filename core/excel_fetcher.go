@@ -0,0 +1,302 @@
+package core
+
+import (
+	"fibr-gen/config"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ExcelDataFetcher implements DataFetcher using .xlsx workbooks, so one report
+// can consume another's output. It maps a view to <RootDir>/<Table>.xlsx, where
+// Table defaults to the view name, or to the files matching its Excel Path.
+// Cells are read as typed values: numbers as int64 / float64, date-formatted
+// numbers as time.Time, booleans as bool and everything else as text.
+type ExcelDataFetcher struct {
+	RootDir  string
+	Provider config.Provider // Optional: resolves view configs (Table, Excel options)
+}
+
+func NewExcelDataFetcher(rootDir string) *ExcelDataFetcher {
+	return &ExcelDataFetcher{RootDir: rootDir}
+}
+
+func (f *ExcelDataFetcher) Fetch(viewName string, params map[string]string) ([]map[string]interface{}, error) {
+	conf, err := lookupViewConfig(f.Provider, viewName)
+	if err != nil {
+		return nil, err
+	}
+	var opts config.ExcelOptions
+	if conf != nil && conf.Excel != nil {
+		opts = *conf.Excel
+	}
+
+	paths, err := resolveViewFiles(f.RootDir, viewName, conf, opts.Path, ".xlsx", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []map[string]interface{}
+	for _, path := range paths {
+		rows, err := readExcelFile(path, &opts)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, filterRows(rows, conf, params)...)
+	}
+	return result, nil
+}
+
+// excelArea is a rectangular block of a sheet, 1-based and inclusive.
+// A zero end means "up to the last used row / column".
+type excelArea struct {
+	Sheet            string
+	StartCol, EndCol int
+	StartRow, EndRow int
+}
+
+func readExcelFile(path string, opts *config.ExcelOptions) ([]map[string]interface{}, error) {
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open workbook %s: %w", path, err)
+	}
+	defer f.Close()
+
+	area, err := locateExcelArea(f, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	rows, err := readExcelArea(f, area)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rows, nil
+}
+
+// locateExcelArea resolves the table, named range or sheet / header row of the options.
+func locateExcelArea(f *excelize.File, opts *config.ExcelOptions) (excelArea, error) {
+	sheet := opts.Sheet
+	if sheet == "" {
+		sheet = f.GetSheetName(0)
+	}
+
+	switch {
+	case opts.Table != "":
+		for _, name := range f.GetSheetList() {
+			tables, err := f.GetTables(name)
+			if err != nil {
+				return excelArea{}, err
+			}
+			for _, tbl := range tables {
+				if tbl.Name == opts.Table {
+					return parseExcelArea(name, tbl.Range)
+				}
+			}
+		}
+		return excelArea{}, fmt.Errorf("excel table '%s' not found", opts.Table)
+
+	case opts.Range != "":
+		for _, dn := range f.GetDefinedName() {
+			if dn.Name == opts.Range && (dn.Scope == "Workbook" || dn.Scope == sheet) {
+				refSheet, ref, ok := strings.Cut(dn.RefersTo, "!")
+				if !ok {
+					return excelArea{}, fmt.Errorf("defined name '%s' does not refer to a range", dn.Name)
+				}
+				refSheet = strings.ReplaceAll(strings.Trim(refSheet, "'"), "''", "'")
+				return parseExcelArea(refSheet, ref)
+			}
+		}
+		return parseExcelArea(sheet, opts.Range)
+
+	default:
+		headerRow := opts.HeaderRow
+		if headerRow == 0 {
+			headerRow = 1
+		}
+		return excelArea{Sheet: sheet, StartCol: 1, StartRow: headerRow}, nil
+	}
+}
+
+// parseExcelArea parses an A1 range such as "A1:D10" or "$A$1:$D$10".
+func parseExcelArea(sheet, ref string) (excelArea, error) {
+	ref = strings.ReplaceAll(ref, "$", "")
+	start, end, ok := strings.Cut(ref, ":")
+	if !ok {
+		end = start
+	}
+	startCol, startRow, err := excelize.CellNameToCoordinates(start)
+	if err != nil {
+		return excelArea{}, fmt.Errorf("invalid range '%s': %w", ref, err)
+	}
+	endCol, endRow, err := excelize.CellNameToCoordinates(end)
+	if err != nil {
+		return excelArea{}, fmt.Errorf("invalid range '%s': %w", ref, err)
+	}
+	return excelArea{Sheet: sheet, StartCol: startCol, EndCol: endCol, StartRow: startRow, EndRow: endRow}, nil
+}
+
+// readExcelArea reads the rows below the area's first (header) row.
+// Columns with a blank header and fully blank rows are skipped.
+func readExcelArea(f *excelize.File, area excelArea) ([]map[string]interface{}, error) {
+	raw, err := f.GetRows(area.Sheet, excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, err
+	}
+	if area.StartRow > len(raw) {
+		return nil, nil // Empty
+	}
+	endRow := len(raw)
+	if area.EndRow > 0 && area.EndRow < endRow {
+		endRow = area.EndRow
+	}
+
+	cellAt := func(row []string, col int) string {
+		if col-1 < len(row) {
+			return row[col-1]
+		}
+		return ""
+	}
+
+	headerRow := raw[area.StartRow-1]
+	endCol := len(headerRow)
+	if area.EndCol > 0 {
+		endCol = area.EndCol
+	}
+	header := make(map[int]string)
+	for col := area.StartCol; col <= endCol; col++ {
+		if name := strings.TrimSpace(cellAt(headerRow, col)); name != "" {
+			header[col] = name
+		}
+	}
+
+	props, err := f.GetWorkbookProps()
+	if err != nil {
+		return nil, err
+	}
+	reader := &excelCellReader{f: f, sheet: area.Sheet, dateStyles: make(map[int]bool)}
+	if props.Date1904 != nil {
+		reader.date1904 = *props.Date1904
+	}
+	var rows []map[string]interface{}
+	for r := area.StartRow + 1; r <= endRow; r++ {
+		item := make(map[string]interface{})
+		for col, name := range header {
+			value := cellAt(raw[r-1], col)
+			if value == "" {
+				continue
+			}
+			typed, err := reader.typedValue(col, r, value)
+			if err != nil {
+				return nil, err
+			}
+			item[name] = typed
+		}
+		if len(item) > 0 {
+			rows = append(rows, item)
+		}
+	}
+	return rows, nil
+}
+
+// excelCellReader converts raw cell text to typed values, caching date style lookups.
+type excelCellReader struct {
+	f          *excelize.File
+	sheet      string
+	dateStyles map[int]bool // style ID -> has a date / time number format
+	date1904   bool         // serial dates count from 1904 (Mac workbooks)
+}
+
+func (r *excelCellReader) typedValue(col, row int, raw string) (interface{}, error) {
+	cell, err := excelize.CoordinatesToCellName(col, row)
+	if err != nil {
+		return nil, err
+	}
+	cellType, err := r.f.GetCellType(r.sheet, cell)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cellType {
+	case excelize.CellTypeBool:
+		return raw == "1" || strings.EqualFold(raw, "true"), nil
+	case excelize.CellTypeDate:
+		if tm, err := time.Parse(time.RFC3339Nano, raw); err == nil {
+			return tm, nil
+		}
+		return raw, nil
+	case excelize.CellTypeNumber, excelize.CellTypeUnset:
+		num, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return raw, nil
+		}
+		isDate, err := r.isDateCell(cell)
+		if err != nil {
+			return nil, err
+		}
+		if isDate {
+			return excelize.ExcelDateToTime(num, r.date1904)
+		}
+		if num == math.Trunc(num) && math.Abs(num) < 1<<53 {
+			return int64(num), nil
+		}
+		return num, nil
+	default:
+		return raw, nil
+	}
+}
+
+func (r *excelCellReader) isDateCell(cell string) (bool, error) {
+	styleID, err := r.f.GetCellStyle(r.sheet, cell)
+	if err != nil || styleID == 0 {
+		return false, err
+	}
+	if isDate, ok := r.dateStyles[styleID]; ok {
+		return isDate, nil
+	}
+	style, err := r.f.GetStyle(styleID)
+	if err != nil {
+		return false, err
+	}
+	isDate := isDateNumFmt(style.NumFmt)
+	if style.CustomNumFmt != nil {
+		isDate = isDateFormatCode(*style.CustomNumFmt)
+	}
+	r.dateStyles[styleID] = isDate
+	return isDate, nil
+}
+
+// isDateNumFmt reports whether a built-in number format displays a date or time.
+func isDateNumFmt(id int) bool {
+	return (id >= 14 && id <= 22) || (id >= 45 && id <= 47)
+}
+
+// isDateFormatCode reports whether a custom number format contains date or time
+// tokens outside quoted text and [...] sections (colors, locales, elapsed time).
+func isDateFormatCode(code string) bool {
+	inQuote, inBracket := false, false
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		switch {
+		case c == '"':
+			inQuote = !inQuote
+		case inQuote:
+		case c == '[':
+			inBracket = true
+		case c == ']':
+			inBracket = false
+		case inBracket:
+		case c == '\\':
+			i++ // escaped literal
+		default:
+			switch c | 0x20 { // lower case
+			case 'y', 'm', 'd', 'h', 's':
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package core
+
+import (
+	"fibr-gen/config"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// writeFinanceWorkbook writes a workbook with a title row, a header on row 2,
+// an Excel table "Payments" and a defined name "Budget".
+func writeFinanceWorkbook(t *testing.T, path string) {
+	t.Helper()
+	f := excelize.NewFile()
+	defer f.Close()
+
+	f.SetSheetName("Sheet1", "Ledger")
+	rows := [][]interface{}{
+		{"Finance export"},
+		{"dept", "amount", "count", "paid_on", "closed", ""},
+		{"D1", 12.5, 3, time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC), true, "ignored"},
+		{},
+		{"D2", 7.25, 1, time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC), false},
+	}
+	for i, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := f.SetSheetRow("Ledger", cell, &row); err != nil {
+			t.Fatalf("SetSheetRow: %v", err)
+		}
+	}
+	dateStyle, _ := f.NewStyle(&excelize.Style{NumFmt: 14})
+	f.SetCellStyle("Ledger", "D3", "D5", dateStyle)
+
+	f.NewSheet("Other")
+	f.SetSheetRow("Other", "A1", &[]interface{}{"region", "target"})
+	f.SetSheetRow("Other", "A2", &[]interface{}{"EU", 100})
+	f.SetSheetRow("Other", "A3", &[]interface{}{"US", 200})
+	if err := f.AddTable("Other", &excelize.Table{Range: "A1:B3", Name: "Payments"}); err != nil {
+		t.Fatalf("AddTable: %v", err)
+	}
+	if err := f.SetDefinedName(&excelize.DefinedName{Name: "Budget", RefersTo: "Other!$A$1:$B$2"}); err != nil {
+		t.Fatalf("SetDefinedName: %v", err)
+	}
+
+	if err := f.SaveAs(path); err != nil {
+		t.Fatalf("SaveAs: %v", err)
+	}
+}
+
+func TestExcelDataFetcher_Fetch(t *testing.T) {
+	dir := t.TempDir()
+	writeFinanceWorkbook(t, filepath.Join(dir, "finance.xlsx"))
+
+	views := map[string]*config.DataViewConfig{
+		"v_ledger": {Name: "v_ledger", Table: "finance", Excel: &config.ExcelOptions{Sheet: "Ledger", HeaderRow: 2}, Labels: []config.LabelConfig{
+			{Name: "dept", Column: "dept"},
+		}},
+		"v_table": {Name: "v_table", Table: "finance", Excel: &config.ExcelOptions{Table: "Payments"}},
+		"v_named": {Name: "v_named", Table: "finance", Excel: &config.ExcelOptions{Range: "Budget"}},
+	}
+	fetcher := NewExcelDataFetcher(dir)
+	fetcher.Provider = config.NewMemoryConfigRegistry(views, nil)
+
+	rows, err := fetcher.Fetch("v_ledger", nil)
+	if err != nil {
+		t.Fatalf("Fetch v_ledger error: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("v_ledger rows = %d, want 2 (blank row skipped)", len(rows))
+	}
+	want := map[string]interface{}{
+		"dept":    "D1",
+		"amount":  12.5,
+		"count":   int64(3),
+		"paid_on": time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC),
+		"closed":  true,
+	}
+	for k, v := range want {
+		if rows[0][k] != v {
+			t.Errorf("row 0 %s = %#v, want %#v", k, rows[0][k], v)
+		}
+	}
+	if len(rows[0]) != len(want) {
+		t.Errorf("row 0 = %v, want only columns with a header", rows[0])
+	}
+
+	rows, err = fetcher.Fetch("v_ledger", map[string]string{"dept": "D2"})
+	if err != nil {
+		t.Fatalf("Fetch filtered error: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["closed"] != false {
+		t.Fatalf("filtered rows = %v, want D2", rows)
+	}
+
+	rows, err = fetcher.Fetch("v_table", nil)
+	if err != nil {
+		t.Fatalf("Fetch v_table error: %v", err)
+	}
+	if len(rows) != 2 || rows[1]["region"] != "US" || rows[1]["target"] != int64(200) {
+		t.Fatalf("v_table rows = %v", rows)
+	}
+
+	rows, err = fetcher.Fetch("v_named", nil)
+	if err != nil {
+		t.Fatalf("Fetch v_named error: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["region"] != "EU" {
+		t.Fatalf("v_named rows = %v, want only EU", rows)
+	}
+}
+
+func TestExcelDataFetcher_Date1904(t *testing.T) {
+	dir := t.TempDir()
+	f := excelize.NewFile()
+	date1904 := true
+	if err := f.SetWorkbookProps(&excelize.WorkbookPropsOptions{Date1904: &date1904}); err != nil {
+		t.Fatalf("SetWorkbookProps: %v", err)
+	}
+	f.SetSheetRow("Sheet1", "A1", &[]interface{}{"paid_on"})
+	f.SetCellValue("Sheet1", "A2", 44268) // 2025-03-14 counted from 1904-01-01
+	dateStyle, _ := f.NewStyle(&excelize.Style{NumFmt: 14})
+	f.SetCellStyle("Sheet1", "A2", "A2", dateStyle)
+	if err := f.SaveAs(filepath.Join(dir, "mac.xlsx")); err != nil {
+		t.Fatalf("SaveAs: %v", err)
+	}
+	f.Close()
+
+	rows, err := NewExcelDataFetcher(dir).Fetch("mac", nil)
+	if err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	if want := time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC); len(rows) != 1 || rows[0]["paid_on"] != want {
+		t.Fatalf("rows = %v, want paid_on %v", rows, want)
+	}
+}
+
+func TestIsDateFormatCode(t *testing.T) {
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{"yyyy-mm-dd", true},
+		{"[$-409]h:mm AM/PM", true},
+		{"#,##0.00", false},
+		{`0 "days"`, false},
+		{"[Red]0.00", false},
+		{"General", false},
+	}
+	for _, tt := range tests {
+		if got := isDateFormatCode(tt.code); got != tt.want {
+			t.Errorf("isDateFormatCode(%q) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fibr-gen/config"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CacheStats counts the lookups of a FetchCache.
+type CacheStats struct {
+	Hits     int // served from memory
+	DiskHits int // served from the disk cache
+	Misses   int // fetched from the data source
+}
+
+// FetchCache memoizes fetched rows by view and the params sent to the fetcher,
+// so that all code paths of a generation run share one fetch per request.
+// Cached rows are shared and must not be modified.
+type FetchCache struct {
+	Disk *DiskCache // Optional: persistent cache shared across runs
+
+	mu      sync.Mutex
+	entries map[string][]map[string]interface{}
+	stats   CacheStats
+}
+
+func NewFetchCache() *FetchCache {
+	return &FetchCache{entries: make(map[string][]map[string]interface{})}
+}
+
+// Fetch returns the cached rows of the view for params, fetching them on a miss.
+// Failed fetches are not cached.
+func (c *FetchCache) Fetch(ctx context.Context, fetcher DataFetcher, viewName string, params map[string]string) ([]map[string]interface{}, error) {
+	key := fetchKey(viewName, params)
+
+	c.mu.Lock()
+	if c.entries == nil {
+		c.entries = make(map[string][]map[string]interface{})
+	}
+	if rows, ok := c.entries[key]; ok {
+		c.stats.Hits++
+		c.mu.Unlock()
+		return rows, nil
+	}
+	c.mu.Unlock()
+
+	if c.Disk != nil {
+		if rows, ok := c.Disk.Get(viewName, params); ok {
+			c.store(key, rows, func(s *CacheStats) { s.DiskHits++ })
+			return rows, nil
+		}
+	}
+
+	rows, err := WithContext(fetcher).FetchContext(ctx, viewName, params)
+	if err != nil {
+		return nil, err
+	}
+	if c.Disk != nil {
+		if err := c.Disk.Put(viewName, params, rows); err != nil {
+			slog.Warn("Failed to write fetch cache", "view", viewName, "error", err)
+		}
+	}
+	c.store(key, rows, func(s *CacheStats) { s.Misses++ })
+	return rows, nil
+}
+
+func (c *FetchCache) store(key string, rows []map[string]interface{}, count func(*CacheStats)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = rows
+	count(&c.stats)
+}
+
+// Stats returns the lookup counts so far.
+func (c *FetchCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// fetchKey identifies a fetch by view name and params, independently of map order.
+func fetchKey(viewName string, params map[string]string) string {
+	if params == nil {
+		params = map[string]string{}
+	}
+	encoded, _ := json.Marshal(params) // Map keys are sorted
+	return viewName + "\x00" + string(encoded)
+}
+
+// DiskCache persists fetched rows in Dir (in the fixture format of
+// RecordingFetcher) so that they can be reused by later runs within TTL.
+// A zero TTL never expires entries.
+//
+// When Configs is set, entries are also keyed by the view's configuration and
+// that of its data source, so that editing them (e.g. the view's Sql) does not
+// serve rows fetched with the old configuration.
+type DiskCache struct {
+	Dir     string
+	TTL     time.Duration
+	Configs config.Provider
+}
+
+// path returns the file of the entry for the view and params.
+func (d *DiskCache) path(viewName string, params map[string]string) string {
+	sum := sha256.Sum256([]byte(fetchKey(viewName, params) + "\x00" + d.configHash(viewName)))
+	return filepath.Join(d.Dir, fixtureDirName(viewName), hex.EncodeToString(sum[:8])+".json")
+}
+
+// configHash identifies the resolved configuration of the view and its data
+// source, or is empty without Configs.
+func (d *DiskCache) configHash(viewName string) string {
+	if d.Configs == nil {
+		return ""
+	}
+	view, err := d.Configs.GetDataViewConfig(viewName)
+	if err != nil {
+		return "" // Reported by the fetch itself
+	}
+	source, _ := d.Configs.GetDataSourceConfig(view.DataSource)
+	encoded, err := json.Marshal(struct {
+		View   *config.DataViewConfig
+		Source *config.DataSourceConfig
+	}{view, source})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
+
+// Get returns the unexpired rows stored for the view and params.
+// Unreadable entries are treated as misses.
+func (d *DiskCache) Get(viewName string, params map[string]string) ([]map[string]interface{}, bool) {
+	path := d.path(viewName, params)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+	if d.TTL > 0 && time.Since(info.ModTime()) > d.TTL {
+		return nil, false
+	}
+	fixture, err := readFixture(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("Ignoring unreadable fetch cache entry", "path", path, "error", err)
+		}
+		return nil, false
+	}
+	return fixture.Rows, true
+}
+
+// Put stores the rows of the view and params.
+func (d *DiskCache) Put(viewName string, params map[string]string, rows []map[string]interface{}) error {
+	return writeFixtureFile(d.path(viewName, params), Fixture{View: viewName, Params: params, Rows: rows})
+}
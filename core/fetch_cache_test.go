@@ -0,0 +1,229 @@
+package core
+
+import (
+	"context"
+	"fibr-gen/config"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestFetchCache_KeyedByViewAndParams(t *testing.T) {
+	fetcher := &countingFetcher{data: map[string][]map[string]interface{}{
+		"v1": {{"ID": "1"}},
+		"v2": {{"ID": "2"}},
+	}}
+	cache := NewFetchCache()
+	ctx := context.Background()
+
+	requests := []struct {
+		view   string
+		params map[string]string
+	}{
+		{"v1", map[string]string{"month": "01", "region": "EU"}},
+		{"v1", map[string]string{"region": "EU", "month": "01"}}, // hit
+		{"v1", map[string]string{"month": "02", "region": "EU"}}, // other params
+		{"v2", map[string]string{"month": "01", "region": "EU"}}, // other view
+		{"v2", map[string]string{"month": "01", "region": "EU"}}, // hit
+	}
+	for _, req := range requests {
+		if _, err := cache.Fetch(ctx, fetcher, req.view, req.params); err != nil {
+			t.Fatalf("Fetch(%s, %v) error: %v", req.view, req.params, err)
+		}
+	}
+
+	if fetcher.calls != 3 {
+		t.Fatalf("fetcher calls = %d, want 3", fetcher.calls)
+	}
+	if stats := cache.Stats(); stats != (CacheStats{Hits: 2, Misses: 3}) {
+		t.Fatalf("stats = %+v, want 2 hits and 3 misses", stats)
+	}
+}
+
+func TestFetchCache_DoesNotCacheErrors(t *testing.T) {
+	cache := NewFetchCache()
+	fetcher := &MockDataFetcher{}
+	for i := 0; i < 2; i++ {
+		if _, err := cache.Fetch(context.Background(), fetcher, "missing", nil); err == nil {
+			t.Fatal("expected error for unknown view")
+		}
+	}
+	if stats := cache.Stats(); stats != (CacheStats{}) {
+		t.Fatalf("stats = %+v, want none", stats)
+	}
+}
+
+func TestDiskCache_TTL(t *testing.T) {
+	dir := t.TempDir()
+	params := map[string]string{"month": "01"}
+	fetcher := &countingFetcher{data: map[string][]map[string]interface{}{
+		"v1": {{"ID": int64(1)}},
+	}}
+
+	first := NewFetchCache()
+	first.Disk = &DiskCache{Dir: dir, TTL: time.Hour}
+	if _, err := first.Fetch(context.Background(), fetcher, "v1", params); err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+
+	// A later run is served from disk.
+	second := NewFetchCache()
+	second.Disk = &DiskCache{Dir: dir, TTL: time.Hour}
+	rows, err := second.Fetch(context.Background(), fetcher, "v1", params)
+	if err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	if fetcher.calls != 1 || len(rows) != 1 || rows[0]["ID"] != int64(1) {
+		t.Fatalf("rows = %v after %d calls, want cached row after 1 call", rows, fetcher.calls)
+	}
+	if stats := second.Stats(); stats.DiskHits != 1 {
+		t.Fatalf("stats = %+v, want 1 disk hit", stats)
+	}
+
+	// Expired entries are fetched again.
+	path := second.Disk.path("v1", params)
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	third := NewFetchCache()
+	third.Disk = &DiskCache{Dir: dir, TTL: time.Hour}
+	if _, err := third.Fetch(context.Background(), fetcher, "v1", params); err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	if fetcher.calls != 2 {
+		t.Fatalf("fetcher calls = %d, want 2 after expiry", fetcher.calls)
+	}
+}
+
+func TestDiskCache_KeyedByViewConfig(t *testing.T) {
+	dir := t.TempDir()
+	params := map[string]string{"month": "01"}
+	fetcher := &countingFetcher{data: map[string][]map[string]interface{}{
+		"v1": {{"ID": int64(1)}},
+	}}
+	view := &config.DataViewConfig{Name: "v1", DataSource: "db", Sql: "SELECT id FROM t1"}
+	configs := config.NewMemoryConfigRegistry(
+		map[string]*config.DataViewConfig{"v1": view},
+		map[string]*config.DataSourceConfig{"db": {Name: "db", Driver: "sqlite"}},
+	)
+	fetch := func() {
+		t.Helper()
+		cache := NewFetchCache()
+		cache.Disk = &DiskCache{Dir: dir, Configs: configs}
+		if _, err := cache.Fetch(context.Background(), fetcher, "v1", params); err != nil {
+			t.Fatalf("Fetch error: %v", err)
+		}
+	}
+
+	fetch()
+	fetch()
+	if fetcher.calls != 1 {
+		t.Fatalf("fetcher calls = %d, want 1 with an unchanged config", fetcher.calls)
+	}
+
+	// Changing the view's Sql misses the cache.
+	view.Sql = "SELECT id FROM t2"
+	fetch()
+	if fetcher.calls != 2 {
+		t.Fatalf("fetcher calls = %d, want 2 after changing the Sql", fetcher.calls)
+	}
+}
+
+func TestDynamicSheet_SharesFetchCache(t *testing.T) {
+	views := map[string]*config.DataViewConfig{
+		"v_months": {
+			Name:   "v_months",
+			Labels: []config.LabelConfig{{Name: "month_id", Column: "month_id"}},
+		},
+	}
+	wbConfig := &config.WorkbookConfig{
+		Sheets: []config.SheetConfig{
+			{
+				Name:         "Sheet",
+				Dynamic:      true,
+				DataViewName: "v_months",
+				ParamLabel:   "month_id",
+				Blocks: []config.BlockConfig{
+					{
+						Name:         "Month",
+						Type:         config.BlockTypeValue,
+						Range:        config.CellRange{Ref: "A1:A1"},
+						DataViewName: "v_months",
+						RowLimit:     1,
+					},
+				},
+			},
+		},
+	}
+	fetcher := &countingFetcher{data: map[string][]map[string]interface{}{
+		"v_months": {{"month_id": "M1"}, {"month_id": "M2"}},
+	}}
+	ctx := NewGenerationContext(wbConfig, config.NewMemoryConfigRegistry(views, nil), fetcher, nil)
+	gen := NewGenerator(ctx)
+
+	f := excelize.NewFile()
+	f.SetSheetName("Sheet1", "Sheet")
+	f.SetCellValue("Sheet", "A1", "{month_id}")
+	if err := gen.processSheet(&ExcelizeFile{file: f}, &wbConfig.Sheets[0]); err != nil {
+		t.Fatalf("processSheet failed: %v", err)
+	}
+
+	if fetcher.calls != 1 {
+		t.Fatalf("fetcher calls = %d, want 1", fetcher.calls)
+	}
+	if stats := ctx.Cache.Stats(); stats.Hits != 2 || stats.Misses != 1 {
+		t.Fatalf("stats = %+v, want 2 hits and 1 miss", stats)
+	}
+	if val, _ := f.GetCellValue("M2", "A1"); val != "M2" {
+		t.Fatalf("M2 A1 = %q, want M2", val)
+	}
+}
+
+func TestFetchCache_SharedAcrossUnrelatedParams(t *testing.T) {
+	views := map[string]*config.DataViewConfig{
+		"v_sales": {
+			Name:       "v_sales",
+			DataSource: "db",
+			Sql:        "SELECT * FROM sales WHERE year = :year",
+			Labels:     []config.LabelConfig{{Name: "region", Column: "REGION"}},
+			Filters:    []config.FilterConfig{{Label: "region", Op: config.FilterOpNe, Value: "${excluded}"}},
+		},
+	}
+	sources := map[string]*config.DataSourceConfig{"db": {Name: "db", Driver: "sqlite"}}
+	provider := config.NewMemoryConfigRegistry(views, sources)
+	fetcher := &countingFetcher{data: map[string][]map[string]interface{}{
+		"v_sales": {{"REGION": "EU"}, {"REGION": "US"}},
+	}}
+	cache := NewFetchCache()
+
+	base := map[string]string{"year": "2025", "excluded": "US", "region": "EU"}
+	for _, unrelated := range []string{"report-a", "report-b"} {
+		params := map[string]string{"workbook": unrelated, "archive_date": unrelated}
+		for k, v := range base {
+			params[k] = v
+		}
+		ctx := NewGenerationContext(&config.WorkbookConfig{}, provider, fetcher, params)
+		ctx.Cache = cache
+		view, err := ctx.GetDataView("v_sales")
+		if err != nil {
+			t.Fatalf("GetDataView error: %v", err)
+		}
+		if len(view.Data) != 1 || view.Data[0]["REGION"] != "EU" {
+			t.Fatalf("rows = %v, want the EU row", view.Data)
+		}
+	}
+
+	if fetcher.calls != 1 {
+		t.Fatalf("fetcher calls = %d, want 1", fetcher.calls)
+	}
+	if !reflect.DeepEqual(fetcher.params[0], base) {
+		t.Errorf("fetch params = %v, want %v", fetcher.params[0], base)
+	}
+	if stats := cache.Stats(); stats != (CacheStats{Hits: 1, Misses: 1}) {
+		t.Fatalf("stats = %+v, want 1 hit and 1 miss", stats)
+	}
+}
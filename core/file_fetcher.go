@@ -0,0 +1,84 @@
+package core
+
+import (
+	"fibr-gen/config"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Helpers shared by the file based fetchers (CSV, JSON, Excel).
+
+// resolveViewFiles returns the files backing a view under rootDir. A non-empty
+// path (a file or glob, ${param} references substituted) takes precedence;
+// otherwise the file is <Table><ext>, where Table may carry its own extension.
+func resolveViewFiles(rootDir, viewName string, conf *config.DataViewConfig, path, ext string, params map[string]string) ([]string, error) {
+	if path == "" {
+		fileName := physicalName(viewName, conf)
+		if filepath.Ext(fileName) == "" {
+			fileName += ext
+		}
+		return []string{filepath.Join(rootDir, fileName)}, nil
+	}
+
+	pattern := replacePlaceholders(path, params)
+	if strings.Contains(pattern, "${") {
+		return nil, fmt.Errorf("path '%s' of view '%s' references missing params", pattern, viewName)
+	}
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(rootDir, pattern)
+	}
+	if !strings.ContainsAny(pattern, "*?[") {
+		return []string{pattern}, nil
+	}
+
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path pattern %s: %w", pattern, err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no files match %s", pattern)
+	}
+	return paths, nil
+}
+
+// filterRows keeps the rows matching every param named after one of their
+// columns, comparing values as text. With a view config only params named
+// after one of its labels or label columns are compared, like the params
+// SQL and DynamoDB fetchers push down, so that unrelated global params never
+// filter rows and fetches depend only on the params fetchParams keeps.
+func filterRows(rows []map[string]interface{}, conf *config.DataViewConfig, params map[string]string) []map[string]interface{} {
+	if conf != nil {
+		params = labelParams(conf, params)
+	}
+	var result []map[string]interface{}
+	for _, item := range rows {
+		match := true
+		for k, v := range params {
+			if colVal, hasCol := item[k]; hasCol {
+				if fmt.Sprintf("%v", colVal) != v {
+					match = false
+					break
+				}
+			}
+		}
+
+		if match {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// labelParams returns the params named after a label or label column of the view.
+func labelParams(conf *config.DataViewConfig, params map[string]string) map[string]string {
+	kept := make(map[string]string)
+	for _, label := range conf.Labels {
+		for _, name := range []string{label.Name, label.Column} {
+			if v, ok := params[name]; ok {
+				kept[name] = v
+			}
+		}
+	}
+	return kept
+}
@@ -0,0 +1,231 @@
+package core
+
+import (
+	"cmp"
+	"fibr-gen/config"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// viewFilters resolves the Filters of a view config against params.
+func viewFilters(conf *config.DataViewConfig, params map[string]string) ([]columnFilter, error) {
+	if conf == nil {
+		return nil, nil
+	}
+	return resolveFilters(conf, conf.Filters, params)
+}
+
+// resolveFilters compiles filter specs into column filters: labels are mapped
+// to the view's columns and ${param} references in values are expanded.
+// Filters referencing an unset parameter are skipped, which makes filters such
+// as "date >= ${start}" optional. Values that cannot be read as the label's
+// type are reported as errors.
+func resolveFilters(conf *config.DataViewConfig, filters []config.FilterConfig, params map[string]string) ([]columnFilter, error) {
+	var resolved []columnFilter
+	for _, spec := range filters {
+		var label *config.LabelConfig
+		for i := range conf.Labels {
+			if conf.Labels[i].Name == spec.Label {
+				label = &conf.Labels[i]
+				break
+			}
+		}
+		if label == nil {
+			return nil, fmt.Errorf("filter label '%s' not found in view '%s'", spec.Label, conf.Name)
+		}
+
+		value, ok := expandFilterValue(spec.Value, params)
+		if !ok {
+			continue
+		}
+		filter := columnFilter{Column: label.Column, Op: spec.Op, Value: value, Type: label.Type}
+		if spec.Op == config.FilterOpIn {
+			filter.Value = ""
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					filter.Values = append(filter.Values, item)
+				}
+			}
+		}
+		if err := filter.checkOperands(); err != nil {
+			return nil, fmt.Errorf("filter on label '%s' of view '%s': %w", spec.Label, conf.Name, err)
+		}
+		resolved = append(resolved, filter)
+	}
+	return resolved, nil
+}
+
+// expandFilterValue replaces ${param} references, reporting false if one is unset.
+func expandFilterValue(value string, params map[string]string) (string, bool) {
+	ok := true
+	expanded := templateVarRe.ReplaceAllStringFunc(value, func(m string) string {
+		v, found := params[m[2:len(m)-1]]
+		if !found {
+			ok = false
+		}
+		return v
+	})
+	return expanded, ok
+}
+
+// checkOperands reports operands that cannot be read as the filter's label type.
+func (f columnFilter) checkOperands() error {
+	if f.Type == "" || f.Type == config.LabelTypeString {
+		return nil
+	}
+	switch f.op() {
+	case config.FilterOpPrefix, config.FilterOpContains, config.FilterOpNull, config.FilterOpNotNull:
+		return nil
+	}
+	for _, operand := range f.operands() {
+		if _, err := CoerceLabelValue(f.Type, operand); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// op returns the filter's operator; parameter filters have none and mean equality.
+func (f columnFilter) op() config.FilterOp {
+	if f.Op == "" {
+		return config.FilterOpEq
+	}
+	return f.Op
+}
+
+// operands returns the values the filter compares against.
+func (f columnFilter) operands() []string {
+	if f.op() == config.FilterOpIn {
+		return f.Values
+	}
+	return []string{f.Value}
+}
+
+// matches evaluates the filter on a row. Like SQL, missing and null values
+// only satisfy the null operator.
+func (f columnFilter) matches(row map[string]interface{}) bool {
+	val, ok := row[f.Column]
+	if ok && f.Type != "" {
+		if coerced, err := CoerceLabelValue(f.Type, val); err == nil {
+			val = coerced
+		}
+	}
+	isNull := !ok || val == nil
+
+	switch op := f.op(); op {
+	case config.FilterOpNull:
+		return isNull
+	case config.FilterOpNotNull:
+		return !isNull
+	case config.FilterOpPrefix, config.FilterOpContains:
+		if isNull {
+			return false
+		}
+		text := formatLabelValue(f.Type, val)
+		if op == config.FilterOpPrefix {
+			return strings.HasPrefix(text, f.Value)
+		}
+		return strings.Contains(text, f.Value)
+	case config.FilterOpIn:
+		if isNull {
+			return false
+		}
+		for _, operand := range f.Values {
+			if order, ok := compareFilterValue(val, operand, f.Type); ok && order == 0 {
+				return true
+			}
+		}
+		return false
+	default:
+		if isNull {
+			return false
+		}
+		order, ok := compareFilterValue(val, f.Value, f.Type)
+		if !ok {
+			return false
+		}
+		switch op {
+		case config.FilterOpEq:
+			return order == 0
+		case config.FilterOpNe:
+			return order != 0
+		case config.FilterOpLt:
+			return order < 0
+		case config.FilterOpLe:
+			return order <= 0
+		case config.FilterOpGt:
+			return order > 0
+		case config.FilterOpGe:
+			return order >= 0
+		}
+		return false
+	}
+}
+
+// compareFilterValue compares a row value with an operand as the label type,
+// or, for untyped labels, as the type of the row value (numbers, dates and
+// booleans; anything else as text). It reports false when the operand cannot
+// be read as that type.
+func compareFilterValue(val interface{}, operand string, t config.LabelType) (int, bool) {
+	if t == "" {
+		switch val.(type) {
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			t = config.LabelTypeDecimal
+		case time.Time:
+			t = config.LabelTypeDateTime
+		case bool:
+			t = config.LabelTypeBool
+		default:
+			t = config.LabelTypeString
+		}
+	}
+	a, err := CoerceLabelValue(t, val)
+	if err != nil || a == nil {
+		return 0, false
+	}
+	b, err := CoerceLabelValue(t, operand)
+	if err != nil || b == nil {
+		return 0, false
+	}
+
+	switch a := a.(type) {
+	case int64:
+		return cmp.Compare(a, b.(int64)), true
+	case float64:
+		return cmp.Compare(a, b.(float64)), true
+	case time.Time:
+		return a.Compare(b.(time.Time)), true
+	case bool:
+		if a == b.(bool) {
+			return 0, true
+		}
+		if !a {
+			return -1, true
+		}
+		return 1, true
+	default:
+		return strings.Compare(fmt.Sprintf("%v", a), fmt.Sprintf("%v", b)), true
+	}
+}
+
+// applyFilters returns the rows matching all filters.
+func applyFilters(rows []map[string]interface{}, filters []columnFilter) []map[string]interface{} {
+	if len(filters) == 0 {
+		return rows
+	}
+	var matched []map[string]interface{}
+	for _, row := range rows {
+		match := true
+		for _, filter := range filters {
+			if !filter.matches(row) {
+				match = false
+				break
+			}
+		}
+		if match {
+			matched = append(matched, row)
+		}
+	}
+	return matched
+}
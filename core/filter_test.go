@@ -0,0 +1,99 @@
+package core
+
+import (
+	"fibr-gen/config"
+	"testing"
+	"time"
+)
+
+func TestColumnFilter_Matches(t *testing.T) {
+	row := map[string]interface{}{
+		"AMOUNT": 150.5,
+		"QTY":    "12", // text, compared as the label's int type
+		"DATE":   time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC),
+		"CODE":   "AB-123",
+		"PAID":   true,
+		"NOTE":   nil,
+		"BLANK":  "",
+	}
+
+	tests := []struct {
+		name   string
+		filter columnFilter
+		want   bool
+	}{
+		{"Numeric gt", columnFilter{Column: "AMOUNT", Op: config.FilterOpGt, Value: "100"}, true},
+		{"Numeric le", columnFilter{Column: "AMOUNT", Op: config.FilterOpLe, Value: "100"}, false},
+		{"Typed int lt", columnFilter{Column: "QTY", Op: config.FilterOpLt, Value: "9", Type: config.LabelTypeInt}, false},
+		{"Untyped text lt", columnFilter{Column: "QTY", Op: config.FilterOpLt, Value: "9"}, true},
+		{"Date ge", columnFilter{Column: "DATE", Op: config.FilterOpGe, Value: "2025-03-01"}, true},
+		{"Date lt", columnFilter{Column: "DATE", Op: config.FilterOpLt, Value: "2025-03-01"}, false},
+		{"Ne", columnFilter{Column: "CODE", Op: config.FilterOpNe, Value: "XY-1"}, true},
+		{"Parameter equality", columnFilter{Column: "CODE", Value: "AB-123"}, true},
+		{"In", columnFilter{Column: "CODE", Op: config.FilterOpIn, Values: []string{"XY-1", "AB-123"}}, true},
+		{"Empty in", columnFilter{Column: "CODE", Op: config.FilterOpIn}, false},
+		{"Prefix", columnFilter{Column: "CODE", Op: config.FilterOpPrefix, Value: "AB-"}, true},
+		{"Contains", columnFilter{Column: "CODE", Op: config.FilterOpContains, Value: "-12"}, true},
+		{"Bool eq", columnFilter{Column: "PAID", Op: config.FilterOpEq, Value: "yes"}, true},
+		{"Null value", columnFilter{Column: "NOTE", Op: config.FilterOpNull}, true},
+		{"Missing column", columnFilter{Column: "OTHER", Op: config.FilterOpNull}, true},
+		{"Blank typed value is null", columnFilter{Column: "BLANK", Op: config.FilterOpNull, Type: config.LabelTypeInt}, true},
+		{"Blank text is not null", columnFilter{Column: "BLANK", Op: config.FilterOpNotNull}, true},
+		{"Null fails comparisons", columnFilter{Column: "NOTE", Op: config.FilterOpNe, Value: "x"}, false},
+		{"Unreadable operand", columnFilter{Column: "AMOUNT", Op: config.FilterOpGt, Value: "many"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.matches(row); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetBlockDataWithParams_Filters(t *testing.T) {
+	views := map[string]*config.DataViewConfig{
+		"v_emp": {
+			Name: "v_emp",
+			Labels: []config.LabelConfig{
+				{Name: "dept", Column: "DEPT"},
+				{Name: "salary", Column: "SALARY", Type: config.LabelTypeInt},
+				{Name: "name", Column: "NAME"},
+			},
+			Filters: []config.FilterConfig{{Label: "dept", Op: config.FilterOpNe, Value: "HR"}},
+		},
+	}
+	fetcher := &countingFetcher{data: map[string][]map[string]interface{}{
+		"v_emp": {
+			{"DEPT": "IT", "SALARY": "5000", "NAME": "Alice"},
+			{"DEPT": "IT", "SALARY": "3000", "NAME": "Bob"},
+			{"DEPT": "HR", "SALARY": "7000", "NAME": "Carol"},
+			{"DEPT": "OPS", "SALARY": "6000", "NAME": "Dave"},
+		},
+	}}
+	ctx := NewGenerationContext(&config.WorkbookConfig{}, config.NewMemoryConfigRegistry(views, nil), fetcher, map[string]string{
+		"min_salary": "4000",
+	})
+	block := &config.BlockConfig{
+		Name:         "HighEarners",
+		Type:         config.BlockTypeValue,
+		DataViewName: "v_emp",
+		Filters: []config.FilterConfig{
+			{Label: "salary", Op: config.FilterOpGe, Value: "${min_salary}"},
+			{Label: "name", Op: config.FilterOpIn, Value: "${names}"}, // skipped: names is unset
+		},
+	}
+
+	rows, err := ctx.GetBlockData(block)
+	if err != nil {
+		t.Fatalf("GetBlockData error: %v", err)
+	}
+	if len(rows) != 2 || rows[0]["NAME"] != "Alice" || rows[1]["NAME"] != "Dave" {
+		t.Fatalf("rows = %v, want Alice and Dave", rows)
+	}
+
+	block.Filters = []config.FilterConfig{{Label: "salary", Op: config.FilterOpGt, Value: "lots"}}
+	if _, err := ctx.GetBlockData(block); err == nil {
+		t.Fatal("expected error for a filter value that is not an int")
+	}
+}
@@ -0,0 +1,266 @@
+package core
+
+import (
+	"fibr-gen/config"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// placeholderRe matches "{label}" and "{label|format|...}" placeholders in template cells.
+var placeholderRe = regexp.MustCompile(`\{([^{}|]+)((?:\|[^{}]*)?)\}`)
+
+// Formatter formats a value for a "{label|name:arg}" placeholder. The value is
+// the label's value coerced to its type (nil when missing), or the text
+// produced by the previous formatter of a chain such as "{name|trim|upper}".
+type Formatter func(value interface{}, arg string) (string, error)
+
+// FormatterRegistry resolves the formatters named in placeholders. Format
+// specs that name no registered formatter are number or date patterns,
+// e.g. "#,##0.00", "0.0%" or "yyyy-MM-dd".
+type FormatterRegistry struct {
+	mu         sync.RWMutex
+	formatters map[string]Formatter
+}
+
+// NewFormatterRegistry returns a registry with the built-in formatters:
+// upper, lower, trim, pad:width[:char] and default:text.
+func NewFormatterRegistry() *FormatterRegistry {
+	r := &FormatterRegistry{formatters: make(map[string]Formatter)}
+	r.Register("upper", func(v interface{}, _ string) (string, error) { return strings.ToUpper(valueText(v)), nil })
+	r.Register("lower", func(v interface{}, _ string) (string, error) { return strings.ToLower(valueText(v)), nil })
+	r.Register("trim", func(v interface{}, _ string) (string, error) { return strings.TrimSpace(valueText(v)), nil })
+	r.Register("pad", padFormatter)
+	r.Register("default", func(v interface{}, arg string) (string, error) {
+		if text := valueText(v); text != "" {
+			return text, nil
+		}
+		return arg, nil
+	})
+	return r
+}
+
+// DefaultFormatters is the registry used by generation contexts unless they set their own.
+var DefaultFormatters = NewFormatterRegistry()
+
+// RegisterFormatter adds a formatter to DefaultFormatters.
+func RegisterFormatter(name string, fn Formatter) {
+	DefaultFormatters.Register(name, fn)
+}
+
+// Register adds or replaces the formatter called name.
+func (r *FormatterRegistry) Register(name string, fn Formatter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.formatters[name] = fn
+}
+
+// Format applies the '|'-separated format spec to value, left to right.
+func (r *FormatterRegistry) Format(value interface{}, spec string) (string, error) {
+	var out interface{} = value
+	for _, stage := range strings.Split(spec, "|") {
+		name, arg, _ := strings.Cut(stage, ":")
+		r.mu.RLock()
+		fn, ok := r.formatters[strings.TrimSpace(name)]
+		r.mu.RUnlock()
+
+		var text string
+		var err error
+		if ok {
+			text, err = fn(out, arg)
+		} else {
+			text, err = formatPattern(out, stage)
+		}
+		if err != nil {
+			return "", fmt.Errorf("format '%s': %w", stage, err)
+		}
+		out = text
+	}
+	return valueText(out), nil
+}
+
+// valueText renders a value as text; times at midnight render as dates.
+func valueText(v interface{}) string {
+	if tm, ok := v.(time.Time); ok && tm.Hour() == 0 && tm.Minute() == 0 && tm.Second() == 0 && tm.Nanosecond() == 0 {
+		return formatLabelValue(config.LabelTypeDate, tm)
+	}
+	return formatLabelValue("", v)
+}
+
+// padFormatter left-pads the text to width with char (default '0').
+func padFormatter(v interface{}, arg string) (string, error) {
+	widthArg, char, found := strings.Cut(arg, ":")
+	width, err := strconv.Atoi(strings.TrimSpace(widthArg))
+	if err != nil || width < 0 {
+		return "", fmt.Errorf("invalid pad width '%s'", widthArg)
+	}
+	if !found || char == "" {
+		char = "0"
+	}
+	text := valueText(v)
+	if n := utf8.RuneCountInString(text); n < width {
+		text = strings.Repeat(char, width-n) + text
+	}
+	return text, nil
+}
+
+// formatPattern formats a value with a number pattern (any pattern with '0'
+// or '#' digit placeholders) or else a date pattern. Missing values format as "".
+func formatPattern(v interface{}, pattern string) (string, error) {
+	if v == nil {
+		return "", nil
+	}
+	if tm, ok := v.(time.Time); ok {
+		return formatDatePattern(tm, pattern), nil
+	}
+	if strings.ContainsAny(pattern, "0#") {
+		n, err := CoerceLabelValue(config.LabelTypeDecimal, v)
+		if err != nil {
+			return "", err
+		}
+		if n == nil {
+			return "", nil
+		}
+		return formatNumberPattern(n.(float64), pattern), nil
+	}
+	tm, err := CoerceLabelValue(config.LabelTypeDateTime, v)
+	if err != nil {
+		return "", fmt.Errorf("unknown formatter or pattern for value %v", v)
+	}
+	if tm == nil {
+		return "", nil
+	}
+	return formatDatePattern(tm.(time.Time), pattern), nil
+}
+
+// formatNumberPattern formats n with an Excel-style number pattern such as
+// "#,##0.00", "0.0%" or "$#,##0": '0' is a required digit, '#' an optional
+// one, ',' groups thousands and '%' scales by 100. Text around the digits is kept.
+func formatNumberPattern(n float64, pattern string) string {
+	first := strings.IndexAny(pattern, "0#")
+	last := strings.LastIndexAny(pattern, "0#")
+	prefix, core, suffix := pattern[:first], pattern[first:last+1], pattern[last+1:]
+	if strings.Contains(prefix+suffix, "%") {
+		n *= 100
+	}
+
+	intPattern, fracPattern, _ := strings.Cut(core, ".")
+	decimals := strings.Count(fracPattern, "0") + strings.Count(fracPattern, "#")
+	minDecimals := strings.Count(fracPattern, "0")
+	minDigits := strings.Count(intPattern, "0")
+
+	digits := strconv.FormatFloat(math.Abs(n), 'f', decimals, 64)
+	intPart, fracPart, _ := strings.Cut(digits, ".")
+	for len(fracPart) > minDecimals && strings.HasSuffix(fracPart, "0") {
+		fracPart = fracPart[:len(fracPart)-1]
+	}
+	if intPart == "0" && minDigits == 0 {
+		intPart = ""
+	}
+	if len(intPart) < minDigits {
+		intPart = strings.Repeat("0", minDigits-len(intPart)) + intPart
+	}
+	if strings.Contains(intPattern, ",") {
+		for i := len(intPart) - 3; i > 0; i -= 3 {
+			intPart = intPart[:i] + "," + intPart[i:]
+		}
+	}
+
+	var sb strings.Builder
+	if n < 0 && strings.Trim(intPart+fracPart, "0,") != "" {
+		sb.WriteByte('-')
+	}
+	sb.WriteString(prefix)
+	sb.WriteString(intPart)
+	if fracPart != "" {
+		sb.WriteByte('.')
+		sb.WriteString(fracPart)
+	}
+	sb.WriteString(suffix)
+	return sb.String()
+}
+
+// formatDatePattern formats tm with a date pattern in the style of Java and
+// .NET: yyyy yy, MMMM MMM MM M, dd d, EEEE EEE, HH H, hh h, mm m, ss s,
+// S (fractions) and a (AM/PM). Text in single quotes is literal.
+func formatDatePattern(tm time.Time, pattern string) string {
+	var sb strings.Builder
+	for i := 0; i < len(pattern); {
+		c := pattern[i]
+		if c == '\'' {
+			end := strings.IndexByte(pattern[i+1:], '\'')
+			if end < 0 {
+				sb.WriteString(pattern[i+1:])
+				break
+			}
+			if end == 0 {
+				sb.WriteByte('\'') // '' is a quote
+			}
+			sb.WriteString(pattern[i+1 : i+1+end])
+			i += end + 2
+			continue
+		}
+		n := 1
+		for i+n < len(pattern) && pattern[i+n] == c {
+			n++
+		}
+		i += n
+
+		pad := func(v int) string {
+			if n >= 2 {
+				return fmt.Sprintf("%02d", v)
+			}
+			return strconv.Itoa(v)
+		}
+		switch c {
+		case 'y':
+			if n == 2 {
+				sb.WriteString(fmt.Sprintf("%02d", tm.Year()%100))
+			} else {
+				sb.WriteString(fmt.Sprintf("%04d", tm.Year()))
+			}
+		case 'M':
+			switch {
+			case n >= 4:
+				sb.WriteString(tm.Month().String())
+			case n == 3:
+				sb.WriteString(tm.Month().String()[:3])
+			default:
+				sb.WriteString(pad(int(tm.Month())))
+			}
+		case 'd':
+			sb.WriteString(pad(tm.Day()))
+		case 'E':
+			if n >= 4 {
+				sb.WriteString(tm.Weekday().String())
+			} else {
+				sb.WriteString(tm.Weekday().String()[:3])
+			}
+		case 'H':
+			sb.WriteString(pad(tm.Hour()))
+		case 'h':
+			sb.WriteString(pad((tm.Hour()+11)%12 + 1))
+		case 'm':
+			sb.WriteString(pad(tm.Minute()))
+		case 's':
+			sb.WriteString(pad(tm.Second()))
+		case 'S':
+			frac := fmt.Sprintf("%09d", tm.Nanosecond())
+			sb.WriteString(frac[:min(n, 9)])
+		case 'a':
+			if tm.Hour() < 12 {
+				sb.WriteString("AM")
+			} else {
+				sb.WriteString("PM")
+			}
+		default:
+			sb.WriteString(pattern[i-n : i])
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,132 @@
+package core
+
+import (
+	"fibr-gen/config"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestFormatterRegistry_Format(t *testing.T) {
+	day := time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC)
+	stamp := time.Date(2025, 3, 4, 15, 7, 9, 120000000, time.UTC)
+	tests := []struct {
+		name    string
+		value   interface{}
+		spec    string
+		want    string
+		wantErr bool
+	}{
+		{"Thousands", 1234567.891, "#,##0.00", "1,234,567.89", false},
+		{"Optional decimals", 2.5, "0.##", "2.5", false},
+		{"Integer from text", "42", "#,##0", "42", false},
+		{"Percent", 0.1234, "0.0%", "12.3%", false},
+		{"Currency prefix", -1234.5, "$#,##0.00", "-$1,234.50", false},
+		{"Negative rounding to zero", -0.001, "0.00", "0.00", false},
+		{"Date", day, "yyyy-MM-dd", "2025-03-04", false},
+		{"Date from text", "2025-03-04", "dd/MM/yy", "04/03/25", false},
+		{"Month names", day, "EEE d MMMM yyyy", "Tue 4 March 2025", false},
+		{"Time", stamp, "hh:mm:ss.SSS a", "03:07:09.120 PM", false},
+		{"Quoted literal", stamp, "yyyy-MM-dd'T'HH:mm", "2025-03-04T15:07", false},
+		{"Upper", "abc", "upper", "ABC", false},
+		{"Chain", "  abc ", "trim|upper", "ABC", false},
+		{"Pad", 42, "pad:6", "000042", false},
+		{"Pad char", "ab", "pad:4:*", "**ab", false},
+		{"Pad shorter", "abcdef", "pad:3", "abcdef", false},
+		{"Default on nil", nil, "default:N/A", "N/A", false},
+		{"Default on value", 0, "default:N/A", "0", false},
+		{"Default after pattern", nil, "#,##0|default:-", "-", false},
+		{"Date value as text", day, "upper", "2025-03-04", false},
+		{"Bad pad", 1, "pad:x", "", true},
+		{"Not a number", "abc", "#,##0", "", true},
+		{"Unknown formatter", "abc", "reverse", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DefaultFormatters.Format(tt.value, tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Format(%v, %q) error = %v, wantErr %v", tt.value, tt.spec, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Format(%v, %q) = %q, want %q", tt.value, tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatterRegistry_Register(t *testing.T) {
+	r := NewFormatterRegistry()
+	r.Register("mask", func(v interface{}, arg string) (string, error) {
+		text := valueText(v)
+		if len(text) <= 4 {
+			return text, nil
+		}
+		return strings.Repeat("*", len(text)-4) + text[len(text)-4:], nil
+	})
+	got, err := r.Format("1234567890", "mask")
+	if err != nil || got != "******7890" {
+		t.Errorf("Format(mask) = %q, %v, want ******7890", got, err)
+	}
+	if _, err := DefaultFormatters.Format("1234567890", "mask"); err == nil {
+		t.Error("formatter registered on a registry leaked into DefaultFormatters")
+	}
+}
+
+func TestValueBlock_FormattedPlaceholders(t *testing.T) {
+	f := excelize.NewFile()
+	sheet := "Sheet1"
+	f.SetCellValue(sheet, "A1", "{code|pad:6}")
+	f.SetCellValue(sheet, "B1", "{name|upper}")
+	f.SetCellValue(sheet, "C1", "{amount|#,##0.00}")
+	f.SetCellValue(sheet, "D1", "Due {day|dd.MM.yyyy}")
+	f.SetCellValue(sheet, "E1", "{note|default:N/A}")
+	f.SetCellValue(sheet, "F1", "{name|shout} ${name} {amount}")
+
+	block := config.BlockConfig{
+		Name:         "Invoices",
+		Type:         config.BlockTypeValue,
+		Range:        config.CellRange{Ref: "A1:F1"},
+		DataViewName: "v_inv",
+	}
+	views := map[string]*config.DataViewConfig{
+		"v_inv": {
+			Name: "v_inv",
+			Labels: []config.LabelConfig{
+				{Name: "code", Column: "CODE", Type: config.LabelTypeInt},
+				{Name: "name", Column: "NAME"},
+				{Name: "amount", Column: "AMOUNT", Type: config.LabelTypeDecimal},
+				{Name: "day", Column: "DAY", Type: config.LabelTypeDate},
+				{Name: "note", Column: "NOTE"},
+			},
+		},
+	}
+	fetcher := &MockFetcher{Data: map[string][]map[string]interface{}{
+		"v_inv": {{"CODE": 42, "NAME": "acme", "AMOUNT": 1234.5, "DAY": "2025-03-14"}},
+	}}
+	ctx := NewGenerationContext(&config.WorkbookConfig{}, config.NewMemoryConfigRegistry(views, nil), fetcher, nil)
+	ctx.Formatters = NewFormatterRegistry()
+	ctx.Formatters.Register("shout", func(v interface{}, _ string) (string, error) {
+		return strings.ToUpper(valueText(v)) + "!", nil
+	})
+	gen := NewGenerator(ctx)
+
+	if err := gen.processBlock(&ExcelizeFile{file: f}, sheet, &block); err != nil {
+		t.Fatalf("processBlock failed: %v", err)
+	}
+
+	want := map[string]string{
+		"A1": "000042",
+		"B1": "ACME",
+		"C1": "1,234.50",
+		"D1": "Due 14.03.2025",
+		"E1": "N/A",
+		"F1": "ACME! ${name} 1234.5",
+	}
+	for cell, value := range want {
+		if got, _ := f.GetCellValue(sheet, cell); got != value {
+			t.Errorf("%s = %q, want %q", cell, got, value)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package core
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// cellRefRe matches A1-style cell references and ranges with an optional sheet
+// qualifier, e.g. B3, $B$3, B3:C4 or 'My Sheet'!B3.
+var cellRefRe = regexp.MustCompile(`((?:'(?:[^']|'')*'|[A-Za-z0-9_.]+)!)?(\$?)([A-Za-z]{1,3})(\$?)([0-9]+)(?::(\$?)([A-Za-z]{1,3})(\$?)([0-9]+))?`)
+
+// cellRef is one corner of a formula reference.
+type cellRef struct {
+	Col, Row       int
+	AbsCol, AbsRow bool
+}
+
+func (r cellRef) String() string {
+	var sb strings.Builder
+	if r.AbsCol {
+		sb.WriteByte('$')
+	}
+	name, _ := excelize.ColumnNumberToName(r.Col)
+	sb.WriteString(name)
+	if r.AbsRow {
+		sb.WriteByte('$')
+	}
+	sb.WriteString(strconv.Itoa(r.Row))
+	return sb.String()
+}
+
+// formulaRef is a cell or range reference found in a formula.
+type formulaRef struct {
+	Sheet string // unquoted sheet qualifier, empty for the formula's own sheet
+	Start cellRef
+	End   *cellRef // nil for a single cell
+}
+
+// rewriteFormulaRefs calls fn for every cell reference of the formula and
+// renders the references as fn left them. String literals, function names
+// such as LOG10 and defined names are left alone.
+func rewriteFormulaRefs(formula string, fn func(ref *formulaRef)) string {
+	var sb strings.Builder
+	for i, segment := range strings.Split(formula, `"`) {
+		if i > 0 {
+			sb.WriteByte('"')
+		}
+		if i%2 == 1 { // Inside a string literal
+			sb.WriteString(segment)
+			continue
+		}
+		last := 0
+		for _, m := range cellRefRe.FindAllStringSubmatchIndex(segment, -1) {
+			if !isRefBoundary(segment, m[0], m[1]) {
+				continue
+			}
+			prefix := ""
+			if m[2] >= 0 {
+				prefix = segment[m[2]:m[3]]
+			}
+			ref := formulaRef{Sheet: unquoteSheet(prefix)}
+			var ok bool
+			if ref.Start, ok = parseCellRef(segment, m[4:12]); !ok {
+				continue
+			}
+			if m[12] >= 0 {
+				end, ok := parseCellRef(segment, m[12:20])
+				if !ok {
+					continue
+				}
+				ref.End = &end
+			}
+
+			fn(&ref)
+			sb.WriteString(segment[last:m[0]])
+			sb.WriteString(prefix)
+			sb.WriteString(ref.Start.String())
+			if ref.End != nil {
+				sb.WriteByte(':')
+				sb.WriteString(ref.End.String())
+			}
+			last = m[1]
+		}
+		sb.WriteString(segment[last:])
+	}
+	return sb.String()
+}
+
+// isRefBoundary reports whether segment[start:end] stands alone as a reference
+// rather than being part of a name or a function call.
+func isRefBoundary(segment string, start, end int) bool {
+	isNameChar := func(c byte) bool {
+		return c == '_' || c == '.' || c >= '0' && c <= '9' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z'
+	}
+	if start > 0 && (isNameChar(segment[start-1]) || segment[start-1] == '$') {
+		return false
+	}
+	if end < len(segment) && (isNameChar(segment[end]) || segment[end] == '(' || segment[end] == '!') {
+		return false
+	}
+	return true
+}
+
+// parseCellRef reads the four submatches ($, column, $, row) of one corner.
+func parseCellRef(segment string, m []int) (cellRef, bool) {
+	col, err := excelize.ColumnNameToNumber(segment[m[2]:m[3]])
+	if err != nil {
+		return cellRef{}, false
+	}
+	row, err := strconv.Atoi(segment[m[6]:m[7]])
+	if err != nil || row < 1 || row > excelize.TotalRows {
+		return cellRef{}, false
+	}
+	return cellRef{Col: col, Row: row, AbsCol: m[1] > m[0], AbsRow: m[5] > m[4]}, true
+}
+
+func unquoteSheet(prefix string) string {
+	name := strings.TrimSuffix(prefix, "!")
+	if strings.HasPrefix(name, "'") && strings.HasSuffix(name, "'") && len(name) >= 2 {
+		name = strings.ReplaceAll(name[1:len(name)-1], "''", "'")
+	}
+	return name
+}
+
+// shiftFormula moves the relative references of a formula copied dCol columns
+// and dRow rows away, like pasting it in Excel. Absolute parts ($B, $3) stay;
+// references that would move off the sheet are kept unchanged.
+func shiftFormula(formula string, dCol, dRow int) string {
+	if dCol == 0 && dRow == 0 {
+		return formula
+	}
+	shift := func(r *cellRef) {
+		if !r.AbsCol && r.Col+dCol >= 1 && r.Col+dCol <= excelize.MaxColumns {
+			r.Col += dCol
+		}
+		if !r.AbsRow && r.Row+dRow >= 1 && r.Row+dRow <= excelize.TotalRows {
+			r.Row += dRow
+		}
+	}
+	return rewriteFormulaRefs(formula, func(ref *formulaRef) {
+		shift(&ref.Start)
+		if ref.End != nil {
+			shift(ref.End)
+		}
+	})
+}
+
+// widenFormulaRanges extends ranges of sheet that span a block's rows (or
+// columns) start..end by count, after count rows (columns) were inserted
+// below (right of) the block. Ranges that end inside the block or beyond it
+// are left to the insertion itself, as in Excel.
+func widenFormulaRanges(formula, sheet string, isRowMode bool, start, end, count int) string {
+	return rewriteFormulaRefs(formula, func(ref *formulaRef) {
+		if ref.End == nil || (ref.Sheet != "" && ref.Sheet != sheet) {
+			return
+		}
+		if isRowMode {
+			if ref.Start.Row <= start && ref.End.Row == end {
+				ref.End.Row += count
+			}
+		} else if ref.Start.Col <= start && ref.End.Col == end {
+			ref.End.Col += count
+		}
+	})
+}
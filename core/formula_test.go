@@ -0,0 +1,246 @@
+package core
+
+import (
+	"fibr-gen/config"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestShiftFormula(t *testing.T) {
+	tests := []struct {
+		name       string
+		formula    string
+		dCol, dRow int
+		want       string
+	}{
+		{"Relative cell", "B3*2", 0, 2, "B5*2"},
+		{"Range", "SUM(B3:D3)", 0, 1, "SUM(B4:D4)"},
+		{"Absolute parts", "$B3+B$3+$B$3", 1, 1, "$B4+C$3+$B$3"},
+		{"Columns", "A1&AZ1", 2, 0, "C1&BB1"},
+		{"Sheet qualified", "Data!A2+'My Sheet'!B2", 0, 1, "Data!A3+'My Sheet'!B3"},
+		{"String literal untouched", `IF(A2="B2","x",B2)`, 0, 1, `IF(A3="B2","x",B3)`},
+		{"Function names untouched", "LOG10(A1)+ATAN2(A1,B1)", 0, 1, "LOG10(A2)+ATAN2(A2,B2)"},
+		{"Off sheet kept", "A1", -1, -1, "A1"},
+		{"No offset", "SUM(b3:b4)", 0, 0, "SUM(b3:b4)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shiftFormula(tt.formula, tt.dCol, tt.dRow); got != tt.want {
+				t.Errorf("shiftFormula(%q, %d, %d) = %q, want %q", tt.formula, tt.dCol, tt.dRow, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWidenFormulaRanges(t *testing.T) {
+	tests := []struct {
+		name      string
+		formula   string
+		isRowMode bool
+		want      string
+	}{
+		{"Total of block", "SUM(B3:B3)", true, "SUM(B3:B5)"},
+		{"Total with header", "SUM(B2:B3)", true, "SUM(B2:B5)"},
+		{"Absolute range", "SUM($B$3:$B$3)", true, "SUM($B$3:$B$5)"},
+		{"Single cell kept", "B3*2", true, "B3*2"},
+		{"Partial range kept", "SUM(B1:B2)", true, "SUM(B1:B2)"},
+		{"Other sheet kept", "SUM(Other!B3:B3)", true, "SUM(Other!B3:B3)"},
+		{"Own sheet qualified", "SUM(Sheet1!B3:B3)", true, "SUM(Sheet1!B3:B5)"},
+		{"Columns", "SUM(C1:C9)", false, "SUM(C1:E9)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := 3, 3
+			if got := widenFormulaRanges(tt.formula, "Sheet1", tt.isRowMode, start, end, 2); got != tt.want {
+				t.Errorf("widenFormulaRanges(%q) = %q, want %q", tt.formula, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValueBlock_Formulas(t *testing.T) {
+	f := excelize.NewFile()
+	sheet := "Sheet1"
+	f.SetCellValue(sheet, "A1", "Item")
+	f.SetCellValue(sheet, "A2", "{name}")
+	f.SetCellValue(sheet, "B2", "{qty}")
+	f.SetCellValue(sheet, "C2", "{price}")
+	f.SetCellFormula(sheet, "D2", "B2*C2")
+	f.SetCellValue(sheet, "A3", "Total")
+	f.SetCellFormula(sheet, "D3", "SUM(D2:D2)")
+	f.SetCellFormula(sheet, "E3", "COUNT(B1:B2)")
+	f.SetSheetDimension(sheet, "A1:E3") // As saved in a template file
+
+	block := config.BlockConfig{
+		Name:         "Lines",
+		Type:         config.BlockTypeValue,
+		Range:        config.CellRange{Ref: "A2:D2"},
+		DataViewName: "v_lines",
+	}
+	views := map[string]*config.DataViewConfig{
+		"v_lines": {
+			Name: "v_lines",
+			Labels: []config.LabelConfig{
+				{Name: "name", Column: "NAME"},
+				{Name: "qty", Column: "QTY", Type: config.LabelTypeInt},
+				{Name: "price", Column: "PRICE", Type: config.LabelTypeDecimal},
+			},
+		},
+	}
+	fetcher := &MockFetcher{Data: map[string][]map[string]interface{}{
+		"v_lines": {
+			{"NAME": "a", "QTY": 1, "PRICE": 2.5},
+			{"NAME": "b", "QTY": 2, "PRICE": 4},
+			{"NAME": "c", "QTY": 3, "PRICE": 1},
+		},
+	}}
+	ctx := NewGenerationContext(&config.WorkbookConfig{}, config.NewMemoryConfigRegistry(views, nil), fetcher, nil)
+	gen := NewGenerator(ctx)
+
+	if err := gen.processBlock(&ExcelizeFile{file: f}, sheet, &block); err != nil {
+		t.Fatalf("processBlock failed: %v", err)
+	}
+
+	want := map[string]string{
+		"D2": "B2*C2",
+		"D3": "B3*C3",
+		"D4": "B4*C4",
+		"D5": "SUM(D2:D4)",
+		"E5": "COUNT(B1:B4)",
+	}
+	for cell, formula := range want {
+		if got, _ := f.GetCellFormula(sheet, cell); got != formula {
+			t.Errorf("%s formula = %q, want %q", cell, got, formula)
+		}
+	}
+	if got, _ := f.CalcCellValue(sheet, "D5"); got != "13.5" {
+		t.Errorf("D5 = %s, want 13.5", got)
+	}
+}
+
+func TestMatrixBlock_Formulas(t *testing.T) {
+	f := excelize.NewFile()
+	sheet := "Sheet1"
+	// Row header in A, column header in row 1, row totals in C, column totals in row 3.
+	f.SetCellValue(sheet, "A1", "Region")
+	f.SetCellValue(sheet, "B1", "{month}")
+	f.SetCellValue(sheet, "C1", "Total")
+	f.SetCellValue(sheet, "A2", "{region}")
+	f.SetCellValue(sheet, "B2", "{amount}")
+	f.SetCellFormula(sheet, "C2", "SUM(B2:B2)")
+	f.SetCellValue(sheet, "A3", "Total")
+	f.SetCellFormula(sheet, "B3", "SUM(B2:B2)")
+	f.SetSheetDimension(sheet, "A1:C3") // As saved in a template file
+
+	block := config.BlockConfig{
+		Name:  "Matrix",
+		Type:  config.BlockTypeMatrix,
+		Range: config.CellRange{Ref: "A1:B2"},
+		SubBlocks: []config.BlockConfig{
+			{Name: "Regions", Type: config.BlockTypeHeader, Direction: config.DirectionVertical, InsertAfter: true, DataViewName: "v_regions", Range: config.CellRange{Ref: "A2:A2"}},
+			{Name: "Months", Type: config.BlockTypeHeader, Direction: config.DirectionHorizontal, InsertAfter: true, DataViewName: "v_months", Range: config.CellRange{Ref: "B1:B1"}},
+			{Name: "Amounts", Type: config.BlockTypeValue, DataViewName: "v_amounts", Range: config.CellRange{Ref: "B2:B2"}},
+		},
+	}
+	views := map[string]*config.DataViewConfig{
+		"v_regions": {Name: "v_regions", Labels: []config.LabelConfig{{Name: "region", Column: "REGION"}}},
+		"v_months":  {Name: "v_months", Labels: []config.LabelConfig{{Name: "month", Column: "MONTH"}}},
+		"v_amounts": {Name: "v_amounts", Labels: []config.LabelConfig{
+			{Name: "region", Column: "REGION"},
+			{Name: "month", Column: "MONTH"},
+			{Name: "amount", Column: "AMOUNT", Type: config.LabelTypeInt},
+		}},
+	}
+	fetcher := &MockFetcher{Data: map[string][]map[string]interface{}{
+		"v_regions": {{"REGION": "East"}, {"REGION": "West"}},
+		"v_months":  {{"MONTH": "Jan"}, {"MONTH": "Feb"}},
+		"v_amounts": {
+			{"REGION": "East", "MONTH": "Jan", "AMOUNT": 1},
+			{"REGION": "East", "MONTH": "Feb", "AMOUNT": 2},
+			{"REGION": "West", "MONTH": "Jan", "AMOUNT": 3},
+			{"REGION": "West", "MONTH": "Feb", "AMOUNT": 4},
+		},
+	}}
+	ctx := NewGenerationContext(&config.WorkbookConfig{}, config.NewMemoryConfigRegistry(views, nil), fetcher, nil)
+	gen := NewGenerator(ctx)
+
+	if err := gen.processBlock(&ExcelizeFile{file: f}, sheet, &block); err != nil {
+		t.Fatalf("processBlock failed: %v", err)
+	}
+	saveTestFile(t, f, "matrix_formulas.xlsx")
+
+	want := map[string]string{
+		"D2": "SUM(B2:C2)", // Row totals widened to both months
+		"D3": "SUM(B3:C3)",
+		"B4": "SUM(B2:B3)", // Column totals widened to both regions
+		"C4": "SUM(C2:C3)",
+	}
+	for cell, formula := range want {
+		if got, _ := f.GetCellFormula(sheet, cell); got != formula {
+			t.Errorf("%s formula = %q, want %q", cell, got, formula)
+		}
+	}
+	values := map[string]string{"D2": "3", "D3": "7", "B4": "4", "C4": "6"}
+	for cell, value := range values {
+		if got, _ := f.CalcCellValue(sheet, cell); got != value {
+			t.Errorf("%s = %s, want %s", cell, got, value)
+		}
+	}
+}
+
+// formulaCountingFile counts formula reads.
+type formulaCountingFile struct {
+	*ExcelizeFile
+	reads int
+}
+
+func (f *formulaCountingFile) GetCellFormula(sheet, cell string) (string, error) {
+	f.reads++
+	return f.ExcelizeFile.GetCellFormula(sheet, cell)
+}
+
+func TestValueBlock_WidensFormulasWithoutRescanning(t *testing.T) {
+	f := excelize.NewFile()
+	sheet := "Sheet1"
+	// Two expanding blocks, one below the other, each with a total below it.
+	f.SetCellValue(sheet, "A1", "{name}")
+	f.SetCellValue(sheet, "B1", "{qty}")
+	f.SetCellFormula(sheet, "B2", "SUM(B1:B1)")
+	f.SetCellValue(sheet, "A4", "{name}")
+	f.SetCellValue(sheet, "B4", "{qty}")
+	f.SetCellFormula(sheet, "B5", "SUM(B4:B4)")
+	f.SetCellFormula(sheet, "C5", "B2+B5")
+	f.SetSheetDimension(sheet, "A1:T50") // A large used range
+
+	sheetConf := &config.SheetConfig{Name: sheet, Blocks: []config.BlockConfig{
+		{Name: "Left", Type: config.BlockTypeValue, Range: config.CellRange{Ref: "A1:B1"}, DataViewName: "v_lines"},
+		{Name: "Bottom", Type: config.BlockTypeValue, Range: config.CellRange{Ref: "A4:B4"}, DataViewName: "v_lines"},
+	}}
+	views := map[string]*config.DataViewConfig{
+		"v_lines": {Name: "v_lines", Labels: []config.LabelConfig{
+			{Name: "name", Column: "NAME"},
+			{Name: "qty", Column: "QTY", Type: config.LabelTypeInt},
+		}},
+	}
+	fetcher := &MockFetcher{Data: map[string][]map[string]interface{}{
+		"v_lines": {{"NAME": "a", "QTY": 1}, {"NAME": "b", "QTY": 2}, {"NAME": "c", "QTY": 3}},
+	}}
+	ctx := NewGenerationContext(&config.WorkbookConfig{}, config.NewMemoryConfigRegistry(views, nil), fetcher, nil)
+	gen := NewGenerator(ctx)
+	file := &formulaCountingFile{ExcelizeFile: &ExcelizeFile{file: f}}
+
+	if err := gen.processSheet(file, sheetConf); err != nil {
+		t.Fatalf("processSheet failed: %v", err)
+	}
+
+	for cell, formula := range map[string]string{"B4": "SUM(B1:B3)", "B9": "SUM(B6:B8)", "C9": "B4+B9"} {
+		if got, _ := f.GetCellFormula(sheet, cell); got != formula {
+			t.Errorf("%s formula = %q, want %q", cell, got, formula)
+		}
+	}
+	// One scan of the used range, not one per insertion
+	if cells := 20 * 54; file.reads > cells {
+		t.Errorf("formula reads = %d, want at most %d", file.reads, cells)
+	}
+}
@@ -1,18 +1,24 @@
 package core
 
 import (
+	"context"
 	"fibr-gen/config"
 	"fmt"
+	"log/slog"
 	"math"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/xuri/excelize/v2"
 )
 
 type Generator struct {
 	Context *GenerationContext
+
+	layouts  map[string]*sheetLayout // by sheet name, for the current run
+	formulas map[string]formulaCells // by sheet name, cells holding formulas of laid out sheets
 }
 
 func NewGenerator(ctx *GenerationContext) *Generator {
@@ -37,7 +43,18 @@ func cloneParams(params map[string]string) map[string]string {
 }
 
 // Generate executes the workbook generation process.
-func (g *Generator) Generate(templateRoot, outputRoot string) (err error) {
+func (g *Generator) Generate(templateRoot, outputRoot string) error {
+	return g.GenerateContext(context.Background(), templateRoot, outputRoot)
+}
+
+// GenerateContext executes the workbook generation process. Cancelling ctx
+// stops generation between blocks and aborts in-flight fetches of fetchers
+// implementing ContextDataFetcher; no output is saved then.
+func (g *Generator) GenerateContext(ctx context.Context, templateRoot, outputRoot string) (err error) {
+	g.Context.runCtx = ctx
+	g.layouts = make(map[string]*sheetLayout)
+	defer func() { g.Context.runCtx = nil; g.layouts = nil; g.formulas = nil }()
+
 	wbConf := g.Context.WorkbookConfig
 	templatePath := filepath.Join(templateRoot, wbConf.Template)
 
@@ -65,11 +82,34 @@ func (g *Generator) Generate(templateRoot, outputRoot string) (err error) {
 		}
 	}(f)
 
+	// Substitute global parameters in static cells before blocks fill in data.
+	// Dynamic sheet templates are substituted per generated sheet instead.
+	dynamicTemplates := make(map[string]bool)
+	for _, sheetConf := range wbConf.Sheets {
+		if sheetConf.Dynamic {
+			dynamicTemplates[sheetConf.Name] = true
+		}
+	}
+	for _, sheet := range f.GetSheetList() {
+		if dynamicTemplates[sheet] {
+			continue
+		}
+		if err := g.substituteSheetParams(f, sheet, g.Context.Parameters); err != nil {
+			return fmt.Errorf("processing sheet %s: %w", sheet, err)
+		}
+	}
+
 	for _, sheetConf := range wbConf.Sheets {
 		if err := g.processSheet(f, &sheetConf); err != nil {
 			return fmt.Errorf("processing sheet %s: %w", sheetConf.Name, err)
 		}
 	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := g.substituteWorkbookParams(f, g.Context.Parameters); err != nil {
+		return err
+	}
 
 	// UX: Reset view to A1 for all sheets and set first sheet active
 	if sheets := f.GetSheetList(); len(sheets) > 0 {
@@ -87,6 +127,11 @@ func (g *Generator) Generate(templateRoot, outputRoot string) (err error) {
 	if err := f.SaveAs(outputPath); err != nil {
 		return fmt.Errorf("failed to save output: %w", err)
 	}
+
+	if cache := g.Context.Cache; cache != nil {
+		stats := cache.Stats()
+		slog.Info("Fetch cache statistics", "hits", stats.Hits, "diskHits", stats.DiskHits, "misses", stats.Misses)
+	}
 	return nil
 }
 
@@ -95,14 +140,48 @@ func (g *Generator) processSheet(f ExcelFile, sheetConf *config.SheetConfig) err
 		return g.processDynamicSheet(f, sheetConf)
 	}
 
+	g.startLayout(sheetConf.Name, sheetConf)
 	for _, block := range sheetConf.Blocks {
-		if err := g.processBlock(f, sheetConf.Name, &block); err != nil {
+		if err := g.processLaidOutBlock(f, sheetConf.Name, &block, g.Context.Parameters); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// startLayout begins tracking the layout of a sheet for the current run.
+func (g *Generator) startLayout(sheetName string, sheetConf *config.SheetConfig) {
+	if g.layouts == nil {
+		g.layouts = make(map[string]*sheetLayout)
+	}
+	g.layouts[sheetName] = newSheetLayout(sheetConf)
+	delete(g.formulas, sheetName)
+}
+
+// processLaidOutBlock processes a top-level block of a sheet at the position
+// its template range moved to after the expansions of the blocks before it.
+func (g *Generator) processLaidOutBlock(f ExcelFile, sheetName string, block *config.BlockConfig, params map[string]string) error {
+	layout := g.layouts[sheetName]
+	if layout == nil {
+		return g.processBlockWithParams(f, sheetName, block, params)
+	}
+	relocated := layout.relocate(block)
+	if err := layout.begin(relocated); err != nil {
+		return err
+	}
+	if err := g.processBlockWithParams(f, sheetName, relocated, params); err != nil {
+		return err
+	}
+	return layout.end()
+}
+
+// recordFill reports cells written by the block being laid out.
+func (g *Generator) recordFill(sheetName string, r config.RangeBounds) {
+	if layout := g.layouts[sheetName]; layout != nil {
+		layout.fill(r)
+	}
+}
+
 func (g *Generator) processBlock(f ExcelFile, sheetName string, block *config.BlockConfig) error {
 	return g.processBlockWithParams(f, sheetName, block, g.Context.Parameters)
 }
@@ -116,11 +195,18 @@ func (g *Generator) processDynamicSheet(f ExcelFile, sheetConf *config.SheetConf
 	}
 
 	// Fetch data to get distinct values for ParamLabel
-	// We need all rows first
-	data, err := g.Context.Fetcher.Fetch(conf.Name, g.Context.Parameters)
+	// We need all rows first (after the view's filters)
+	vv, err := g.Context.sharedDataView(sheetConf.DataViewName)
 	if err != nil {
 		return fmt.Errorf("failed to fetch dynamic sheet data: %w", err)
 	}
+	data := vv.Data
+	if len(sheetConf.Sort) > 0 {
+		// Sheets are created in the order of first appearance
+		if data, err = sortRows(conf, data, sheetConf.Sort); err != nil {
+			return fmt.Errorf("dynamic sheet '%s': %w", sheetConf.Name, err)
+		}
+	}
 
 	// Distinct values
 	distinctValues := make(map[string]struct{})
@@ -175,8 +261,12 @@ func (g *Generator) processDynamicSheet(f ExcelFile, sheetConf *config.SheetConf
 		// We need to inject the parameter for this sheet (e.g. month=January)
 		sheetParams := cloneParams(g.Context.Parameters)
 		sheetParams[sheetConf.ParamLabel] = val
+		if err := g.substituteSheetParams(f, newSheetName, sheetParams); err != nil {
+			return err
+		}
 
 		// Process each block in the NEW sheet
+		g.startLayout(newSheetName, sheetConf)
 		for _, block := range sheetConf.Blocks {
 			// We need to pass the sheetParams down.
 			// But processBlock calls processValueBlock which uses g.Context.Parameters.
@@ -185,7 +275,7 @@ func (g *Generator) processDynamicSheet(f ExcelFile, sheetConf *config.SheetConf
 			// Passing params is better.
 
 			// We need a helper processBlockWithParams
-			if err := g.processBlockWithParams(f, newSheetName, &block, sheetParams); err != nil {
+			if err := g.processLaidOutBlock(f, newSheetName, &block, sheetParams); err != nil {
 				return err
 			}
 		}
@@ -194,12 +284,17 @@ func (g *Generator) processDynamicSheet(f ExcelFile, sheetConf *config.SheetConf
 	// Delete Template Sheet if we generated others?
 	if len(values) > 0 {
 		f.DeleteSheet(templateSheetName)
+	} else if err := g.substituteSheetParams(f, templateSheetName, g.Context.Parameters); err != nil {
+		return err
 	}
 
 	return nil
 }
 
 func (g *Generator) processBlockWithParams(f ExcelFile, sheetName string, block *config.BlockConfig, params map[string]string) error {
+	if err := g.Context.Context().Err(); err != nil {
+		return err
+	}
 	switch block.Type {
 	case config.BlockTypeValue:
 		return g.processValueBlockWithParams(f, sheetName, block, params)
@@ -221,21 +316,9 @@ func (g *Generator) processBlockWithParams(f ExcelFile, sheetName string, block
 
 func (g *Generator) processMatrixBlockWithParams(f ExcelFile, sheetName string, block *config.BlockConfig, params map[string]string) error {
 	// 1. Identify Axes
-	var vH, hH *config.BlockConfig
-	for i := range block.SubBlocks {
-		sb := &block.SubBlocks[i]
-		if sb.Type == config.BlockTypeHeader {
-			switch sb.Direction {
-			case config.DirectionVertical, "":
-				vH = sb
-			case config.DirectionHorizontal:
-				hH = sb
-			}
-		}
-	}
-
-	if vH == nil || hH == nil {
-		return fmt.Errorf("MatrixBlock %s must have both vertical and horizontal axes", block.Name)
+	vH, hH, err := config.MatrixHeaders(block)
+	if err != nil {
+		return err
 	}
 
 	// 2. Determine Expansion Mode
@@ -243,7 +326,6 @@ func (g *Generator) processMatrixBlockWithParams(f ExcelFile, sheetName string,
 
 	var headerData []map[string]interface{}
 	var staticData []map[string]interface{}
-	var err error
 
 	// 3. Process Axes
 	if isVerticalExpand {
@@ -266,7 +348,7 @@ func (g *Generator) processMatrixBlockWithParams(f ExcelFile, sheetName string,
 			}
 			axisHeight := endRow - startRow + 1
 			insertCount := (dataCount - 1) * axisHeight
-			if err := f.InsertRows(sheetName, endRow+1, insertCount); err != nil {
+			if err := g.insertSlice(f, sheetName, true, startRow, endRow, insertCount); err != nil {
 				return err
 			}
 		}
@@ -284,8 +366,7 @@ func (g *Generator) processMatrixBlockWithParams(f ExcelFile, sheetName string,
 			}
 			axisWidth := endCol - startCol + 1
 			insertCount := (len(staticData) - 1) * axisWidth
-			colName, _ := excelize.ColumnNumberToName(endCol + 1)
-			if err := f.InsertCols(sheetName, colName, insertCount); err != nil {
+			if err := g.insertSlice(f, sheetName, false, startCol, endCol, insertCount); err != nil {
 				return err
 			}
 
@@ -337,8 +418,7 @@ func (g *Generator) processMatrixBlockWithParams(f ExcelFile, sheetName string,
 			}
 			axisWidth := endCol - startCol + 1
 			insertCount := (dataCount - 1) * axisWidth
-			colName, _ := excelize.ColumnNumberToName(endCol + 1)
-			if err := f.InsertCols(sheetName, colName, insertCount); err != nil {
+			if err := g.insertSlice(f, sheetName, false, startCol, endCol, insertCount); err != nil {
 				return err
 			}
 
@@ -426,34 +506,35 @@ func (g *Generator) processMatrixBlockWithParams(f ExcelFile, sheetName string,
 	hStartCol, _, hEndCol, _, _ := parseRange(hH.Range.Ref)
 	hStep := hEndCol - hStartCol + 1
 
+	// Resolve Label Name -> Column Name first!
+	getLabelName := func(dataViewName, labelName string) string {
+		conf, err := g.Context.ConfigProvider.GetDataViewConfig(dataViewName)
+		if err != nil {
+			return ""
+		}
+		for _, t := range conf.Labels {
+			if t.Name == labelName {
+				return t.Column
+			}
+		}
+		return ""
+	}
+	vCol := getLabelName(vH.DataViewName, vKey)
+	hCol := getLabelName(hH.DataViewName, hKey)
+
 	// Iterate Grid & Fill (Write-Many)
+	// Cell data is looked up in the DataViews' hash indexes on vKey / hKey.
 	for r, rowItem := range rows {
 		for c, colItem := range cols {
 			// Construct parameters for this cell
 			cellParams := cloneParams(params)
 
-			// Resolve Label Name -> Column Name first!
-			getLabelName := func(dataViewName, labelName string) string {
-				conf, err := g.Context.ConfigProvider.GetDataViewConfig(dataViewName)
-				if err != nil {
-					return ""
-				}
-				for _, t := range conf.Labels {
-					if t.Name == labelName {
-						return t.Column
-					}
-				}
-				return ""
-			}
-
-			vCol := getLabelName(vH.DataViewName, vKey)
 			if vCol != "" {
 				if val, ok := rowItem[vCol]; ok {
 					cellParams[vKey] = fmt.Sprintf("%v", val)
 				}
 			}
 
-			hCol := getLabelName(hH.DataViewName, hKey)
 			if hCol != "" {
 				if val, ok := colItem[hCol]; ok {
 					cellParams[hKey] = fmt.Sprintf("%v", val)
@@ -486,6 +567,15 @@ func (g *Generator) processMatrixBlockWithParams(f ExcelFile, sheetName string,
 			}
 		}
 	}
+	for _, cache := range cachedTemplates {
+		if len(rows) > 0 && len(cols) > 0 {
+			g.recordFill(sheetName, config.RangeBounds{
+				StartCol: cache.StartCol, StartRow: cache.StartRow,
+				EndCol: cache.StartCol + (len(cols)-1)*hStep + cache.Width - 1,
+				EndRow: cache.StartRow + (len(rows)-1)*vStep + cache.Height - 1,
+			})
+		}
+	}
 
 	return nil
 }
@@ -530,20 +620,17 @@ func (g *Generator) copyTemplateSlice(f ExcelFile, sheetName string, block *conf
 func (g *Generator) copySlice(f ExcelFile, sheet string, isRowMode bool, srcStart, srcEnd, destStart, count int) error {
 	srcSize := srcEnd - srcStart + 1
 	type cellData struct {
-		val   string
-		style int
+		val     string
+		formula string
+		style   int
 	}
 	srcMap := make(map[int]cellData)
 
 	// Determine limits
-	dims, err := f.GetSheetDimension(sheet)
+	maxC, maxR, err := sheetBounds(f, sheet)
 	if err != nil {
 		return err
 	}
-	_, _, maxC, maxR, err := parseRange(dims)
-	if err != nil {
-		maxC, maxR = 100, 1000 // Fallback
-	}
 
 	limit := maxC
 	if !isRowMode {
@@ -561,10 +648,11 @@ func (g *Generator) copySlice(f ExcelFile, sheet string, isRowMode bool, srcStar
 			}
 			cn, _ := excelize.CoordinatesToCellName(c, r)
 			val, _ := f.GetCellValue(sheet, cn)
+			formula, _ := f.GetCellFormula(sheet, cn)
 			style, _ := f.GetCellStyle(sheet, cn)
 			// Key: (PrimaryOffset * 10000) + SecondaryIndex
 			key := (p-srcStart)*10000 + s
-			srcMap[key] = cellData{val, style}
+			srcMap[key] = cellData{val, formula, style}
 		}
 	}
 
@@ -577,13 +665,21 @@ func (g *Generator) copySlice(f ExcelFile, sheet string, isRowMode bool, srcStar
 			key := srcOffset*10000 + s
 			if data, ok := srcMap[key]; ok {
 				var c, r int
+				var dCol, dRow int
 				if isRowMode {
 					c, r = s, destP
+					dRow = destP - (srcStart + srcOffset)
 				} else {
 					c, r = destP, s
+					dCol = destP - (srcStart + srcOffset)
 				}
 				cn, _ := excelize.CoordinatesToCellName(c, r)
-				_ = f.SetCellValue(sheet, cn, data.val)
+				if data.formula != "" {
+					// Relative references follow the copy, as when pasting in Excel
+					_ = g.setCellFormula(f, sheet, cn, shiftFormula(data.formula, dCol, dRow))
+				} else {
+					_ = f.SetCellValue(sheet, cn, data.val)
+				}
 				if data.style != 0 {
 					_ = f.SetCellStyle(sheet, cn, cn, data.style)
 				}
@@ -593,6 +689,182 @@ func (g *Generator) copySlice(f ExcelFile, sheet string, isRowMode bool, srcStar
 	return nil
 }
 
+// insertSlice inserts count rows (if isRowMode) or columns after a block
+// spanning start..end, then widens the formulas totalling the block so that
+// they cover the inserted rows or columns too.
+func (g *Generator) insertSlice(f ExcelFile, sheet string, isRowMode bool, start, end, count int) error {
+	at := end + 1
+	layout := g.layouts[sheet]
+	if layout != nil {
+		hasContent := func(col, row int) bool {
+			cn, _ := excelize.CoordinatesToCellName(col, row)
+			value, _ := f.GetCellValue(sheet, cn)
+			formula, _ := f.GetCellFormula(sheet, cn)
+			return value != "" || formula != ""
+		}
+		if !isRowMode {
+			// Without inserted columns the expansion must not overwrite the template
+			if err := layout.checkExpansion(at, count, hasContent); err != nil {
+				return err
+			}
+		}
+		// Rows already inserted here by a block alongside are reused, and no
+		// rows are inserted through the blocks alongside
+		var err error
+		if at, count, err = layout.reserve(isRowMode, at, count, hasContent); err != nil {
+			return err
+		}
+		if count == 0 {
+			return nil
+		}
+		end = at - 1
+	}
+
+	maxC, maxR, err := sheetBounds(f, sheet)
+	if err != nil {
+		return err
+	}
+	if isRowMode {
+		if err := f.InsertRows(sheet, at, count); err != nil {
+			return err
+		}
+		maxR += count
+	} else {
+		// Excelize InsertCols takes column name "C"
+		colName, err := excelize.ColumnNumberToName(at)
+		if err != nil {
+			return err
+		}
+		if err := f.InsertCols(sheet, colName, count); err != nil {
+			return err
+		}
+		maxC += count
+	}
+	if layout != nil {
+		layout.record(isRowMode, at, count)
+	}
+	if cells, ok := g.formulas[sheet]; ok {
+		g.formulas[sheet] = cells.shift(isRowMode, at, count)
+	}
+	// Inserting does not update the used range, which later scans rely on
+	dims, _ := excelize.CoordinatesToCellName(maxC, maxR)
+	if err := f.SetSheetDimension(sheet, "A1:"+dims); err != nil {
+		return err
+	}
+	return g.widenFormulas(f, sheet, isRowMode, start, end, count)
+}
+
+// widenFormulas rewrites the formulas of the sheet whose ranges span the
+// block start..end, e.g. =SUM(B3:B3) below a one-row block at row 3 becomes
+// =SUM(B3:B5) after two rows were inserted. Formulas within the block's rows
+// (columns) are per-record and are shifted by copySlice/fillTemplate instead.
+func (g *Generator) widenFormulas(f ExcelFile, sheet string, isRowMode bool, start, end, count int) error {
+	cells, err := g.formulaCells(f, sheet)
+	if err != nil {
+		return err
+	}
+	for cell := range cells {
+		if isRowMode && cell.Row >= start && cell.Row <= end+count {
+			continue
+		}
+		if !isRowMode && cell.Col >= start && cell.Col <= end+count {
+			continue
+		}
+		cn, _ := excelize.CoordinatesToCellName(cell.Col, cell.Row)
+		formula, err := f.GetCellFormula(sheet, cn)
+		if err != nil || formula == "" {
+			continue
+		}
+		if widened := widenFormulaRanges(formula, sheet, isRowMode, start, end, count); widened != formula {
+			if err := f.SetCellFormula(sheet, cn, widened); err != nil {
+				return fmt.Errorf("failed to widen formula in %s: %w", cn, err)
+			}
+		}
+	}
+	return nil
+}
+
+// cellCoord is a 1-based cell position.
+type cellCoord struct {
+	Col, Row int
+}
+
+// formulaCells is the set of cells of a sheet that hold a formula. It may
+// keep cells whose formula was since overwritten.
+type formulaCells map[cellCoord]struct{}
+
+// shift returns the cells moved past count rows (columns) inserted before row (column) at.
+func (c formulaCells) shift(isRowMode bool, at, count int) formulaCells {
+	ins := layoutInsert{rows: isRowMode, at: at, count: count}
+	shifted := make(formulaCells, len(c))
+	for cell := range c {
+		if isRowMode {
+			cell.Row = ins.shift(cell.Row)
+		} else {
+			cell.Col = ins.shift(cell.Col)
+		}
+		shifted[cell] = struct{}{}
+	}
+	return shifted
+}
+
+// formulaCells returns the formula cells of the sheet. They are collected by
+// scanning the sheet once per laid out sheet, then kept up to date by
+// insertSlice and setCellFormula, so that widening the formulas after every
+// expansion does not scan the whole sheet again.
+func (g *Generator) formulaCells(f ExcelFile, sheet string) (formulaCells, error) {
+	if cells, ok := g.formulas[sheet]; ok {
+		return cells, nil
+	}
+	maxC, maxR, err := sheetBounds(f, sheet)
+	if err != nil {
+		return nil, err
+	}
+	cells := make(formulaCells)
+	for r := 1; r <= maxR; r++ {
+		for c := 1; c <= maxC; c++ {
+			cn, _ := excelize.CoordinatesToCellName(c, r)
+			if formula, err := f.GetCellFormula(sheet, cn); err == nil && formula != "" {
+				cells[cellCoord{Col: c, Row: r}] = struct{}{}
+			}
+		}
+	}
+	// Only laid out sheets are tracked for the rest of the run
+	if g.layouts[sheet] != nil {
+		if g.formulas == nil {
+			g.formulas = make(map[string]formulaCells)
+		}
+		g.formulas[sheet] = cells
+	}
+	return cells, nil
+}
+
+// sheetBounds returns the last column and row of the sheet's used range.
+func sheetBounds(f ExcelFile, sheet string) (int, int, error) {
+	dims, err := f.GetSheetDimension(sheet)
+	if err != nil {
+		return 0, 0, err
+	}
+	if _, _, maxC, maxR, err := parseRange(dims); err == nil {
+		return maxC, maxR, nil
+	}
+	return 100, 1000, nil // Fallback
+}
+
+// setCellFormula writes a formula unless the cell already holds it, which
+// keeps shared formulas of the template intact.
+func (g *Generator) setCellFormula(f ExcelFile, sheet, cell, formula string) error {
+	if cells, ok := g.formulas[sheet]; ok {
+		if col, row, err := excelize.CellNameToCoordinates(cell); err == nil {
+			cells[cellCoord{Col: col, Row: row}] = struct{}{}
+		}
+	}
+	if current, err := f.GetCellFormula(sheet, cell); err == nil && current == formula {
+		return nil
+	}
+	return f.SetCellFormula(sheet, cell, formula)
+}
+
 // copyRows copies a range of rows to a new location, replicating them count times.
 func (g *Generator) copyRows(f ExcelFile, sheet string, srcStartRow, srcEndRow, destStartRow, insertHeight int) error {
 	return g.copySlice(f, sheet, true, srcStartRow, srcEndRow, destStartRow, insertHeight)
@@ -641,15 +913,13 @@ func (g *Generator) processValueBlockWithParams(f ExcelFile, sheetName string, b
 		if isVertical {
 			insertCount := (dataCount - 1) * blockHeight
 			// Insert after the block's bottom
-			if err := f.InsertRows(sheetName, endRow+1, insertCount); err != nil {
+			if err := g.insertSlice(f, sheetName, true, startRow, endRow, insertCount); err != nil {
 				return fmt.Errorf("failed to insert rows: %w", err)
 			}
 		} else {
 			insertCount := (dataCount - 1) * blockWidth
 			// Insert after the block's right
-			// Excelize InsertCols takes column name "C"
-			colName, _ := excelize.ColumnNumberToName(endCol + 1)
-			if err := f.InsertCols(sheetName, colName, insertCount); err != nil {
+			if err := g.insertSlice(f, sheetName, false, startCol, endCol, insertCount); err != nil {
 				return fmt.Errorf("failed to insert cols: %w", err)
 			}
 		}
@@ -662,8 +932,9 @@ func (g *Generator) processValueBlockWithParams(f ExcelFile, sheetName string, b
 // CellData fillBlockData fills a block with data, handling template caching and label replacement.
 // It assumes any necessary expansion (inserting rows/cols) has already been done.
 type CellData struct {
-	Val   string
-	Style int
+	Val     string
+	Formula string
+	Style   int
 }
 
 type RelativeMerge struct {
@@ -693,8 +964,9 @@ func (g *Generator) captureTemplate(f ExcelFile, sheetName string, block *config
 		for c := range w {
 			cn, _ := excelize.CoordinatesToCellName(c1+c, r1+r)
 			val, _ := f.GetCellValue(sheetName, cn)
+			formula, _ := f.GetCellFormula(sheetName, cn)
 			sty, _ := f.GetCellStyle(sheetName, cn)
-			cells[r][c] = CellData{Val: val, Style: sty}
+			cells[r][c] = CellData{Val: val, Formula: formula, Style: sty}
 		}
 	}
 
@@ -736,16 +1008,33 @@ func (g *Generator) captureTemplate(f ExcelFile, sheetName string, block *config
 	}, nil
 }
 
+// labelValue is a label's value for one data row, coerced to the label's type.
+type labelValue struct {
+	Type  config.LabelType
+	Value interface{}
+}
+
+// native reports whether the value should be written as a typed (numeric, date, bool) cell.
+func (lv labelValue) native() bool {
+	return lv.Type != "" && lv.Type != config.LabelTypeString
+}
+
 func (g *Generator) fillTemplate(f ExcelFile, sheetName string, cache *TemplateCache, targetCol, targetRow int, data map[string]interface{}) error {
 	// Replacement map
-	rep := make(map[string]interface{})
-	if data != nil {
-		vv, err := g.Context.ConfigProvider.GetDataViewConfig(cache.Block.DataViewName)
-		if err == nil {
-			for _, t := range vv.Labels {
-				if v, ok := data[t.Column]; ok {
-					rep[t.Name] = v
+	rep := make(map[string]labelValue)
+	labels := make(map[string]bool)
+	if vv, err := g.Context.ConfigProvider.GetDataViewConfig(cache.Block.DataViewName); err == nil {
+		for _, t := range vv.Labels {
+			labels[t.Name] = true
+			if v, ok := data[t.Column]; ok {
+				coerced, err := CoerceLabelValue(t.Type, v)
+				if err != nil {
+					slog.Warn("Label type coercion failed, writing raw value",
+						"label", t.Name, "type", t.Type, "value", v, "error", err)
+					rep[t.Name] = labelValue{Value: v}
+					continue
 				}
+				rep[t.Name] = labelValue{Type: t.Type, Value: coerced}
 			}
 		}
 	}
@@ -756,23 +1045,39 @@ func (g *Generator) fillTemplate(f ExcelFile, sheetName string, cache *TemplateC
 			val := cell.Val
 			style := cell.Style
 
-			// Replace
-			for t, v := range rep {
-				ph := fmt.Sprintf("{%s}", t)
-				if strings.Contains(val, ph) {
-					val = strings.ReplaceAll(val, ph, fmt.Sprintf("%v", v))
-				}
-			}
-
 			tcn, _ := excelize.CoordinatesToCellName(targetCol+c, targetRow+r)
-			if err := f.SetCellValue(sheetName, tcn, val); err != nil {
-				return err
-			}
+			// Style first: writing a time value adjusts the cell's number format.
 			if style != 0 {
 				if err := f.SetCellStyle(sheetName, tcn, tcn, style); err != nil {
 					return err
 				}
 			}
+
+			// Formulas are copied with their relative references moved to the target.
+			if cell.Formula != "" {
+				formula := shiftFormula(cell.Formula, targetCol-cache.StartCol, targetRow-cache.StartRow)
+				if err := g.setCellFormula(f, sheetName, tcn, formula); err != nil {
+					return err
+				}
+				continue
+			}
+
+			// A cell consisting solely of a typed placeholder keeps its native type.
+			if name, ok := soleLabel(val); ok {
+				if lv, ok := rep[name]; ok && lv.native() {
+					if err := g.setTypedCellValue(f, sheetName, tcn, lv); err != nil {
+						return err
+					}
+					continue
+				}
+			}
+
+			// Replace
+			val = g.expandPlaceholders(val, rep, labels)
+
+			if err := f.SetCellValue(sheetName, tcn, val); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -798,6 +1103,94 @@ func (g *Generator) fillTemplate(f ExcelFile, sheetName string, cache *TemplateC
 	return nil
 }
 
+// expandPlaceholders replaces the "{label}" and "{label|format}" placeholders
+// of a cell. Formatted placeholders of the view's labels are expanded even
+// without a value, so that e.g. "{amount|default:-}" can fill empty cells;
+// other placeholders without a value, and "${param}" references, are kept.
+func (g *Generator) expandPlaceholders(val string, rep map[string]labelValue, labels map[string]bool) string {
+	formatters := g.Context.Formatters
+	if formatters == nil {
+		formatters = DefaultFormatters
+	}
+
+	var sb strings.Builder
+	last := 0
+	for _, m := range placeholderRe.FindAllStringSubmatchIndex(val, -1) {
+		if m[0] > 0 && val[m[0]-1] == '$' {
+			continue
+		}
+		name, spec := val[m[2]:m[3]], val[m[4]:m[5]]
+		lv, ok := rep[name]
+		if !ok && (spec == "" || !labels[name]) {
+			continue
+		}
+
+		text := formatLabelValue(lv.Type, lv.Value)
+		if spec != "" {
+			formatted, err := formatters.Format(lv.Value, spec[1:])
+			if err != nil {
+				slog.Warn("Placeholder format failed, writing unformatted value",
+					"label", name, "format", spec[1:], "value", lv.Value, "error", err)
+			} else {
+				text = formatted
+			}
+		}
+		sb.WriteString(val[last:m[0]])
+		sb.WriteString(text)
+		last = m[1]
+	}
+	sb.WriteString(val[last:])
+	return sb.String()
+}
+
+// soleLabel returns the label name when the cell text is exactly one "{label}" placeholder.
+func soleLabel(val string) (string, bool) {
+	if len(val) < 3 || val[0] != '{' || val[len(val)-1] != '}' {
+		return "", false
+	}
+	name := val[1 : len(val)-1]
+	if strings.ContainsAny(name, "{}") {
+		return "", false
+	}
+	return name, true
+}
+
+// setTypedCellValue writes a coerced label value as a native Excel cell.
+// Dates and datetimes get a matching number format on top of the cell's style,
+// unless the template cell has a number format of its own.
+func (g *Generator) setTypedCellValue(f ExcelFile, sheetName, cell string, lv labelValue) error {
+	if lv.Value == nil {
+		return f.SetCellValue(sheetName, cell, "")
+	}
+	// Read the template style first: writing a time sets its own number format.
+	style := &excelize.Style{}
+	styleID, err := f.GetCellStyle(sheetName, cell)
+	if err == nil && styleID != 0 {
+		if existing, err := f.GetStyle(styleID); err == nil {
+			style = existing
+		}
+	}
+	if err := f.SetCellValue(sheetName, cell, lv.Value); err != nil {
+		return err
+	}
+	if _, ok := lv.Value.(time.Time); !ok {
+		return nil
+	}
+	if style.NumFmt != 0 || style.CustomNumFmt != nil {
+		return f.SetCellStyle(sheetName, cell, cell, styleID)
+	}
+
+	style.NumFmt = 22 // built-in date + time
+	if lv.Type == config.LabelTypeDate {
+		style.NumFmt = 14 // built-in short date
+	}
+	dateStyleID, err := f.NewStyle(style)
+	if err != nil {
+		return err
+	}
+	return f.SetCellStyle(sheetName, cell, cell, dateStyleID)
+}
+
 func (g *Generator) fillBlockData(f ExcelFile, sheetName string, block *config.BlockConfig, data []map[string]interface{}) error {
 	// Capture Template
 	cache, err := g.captureTemplate(f, sheetName, block)
@@ -820,23 +1213,19 @@ func (g *Generator) fillBlockData(f ExcelFile, sheetName string, block *config.B
 		if err := g.fillTemplate(f, sheetName, cache, cache.StartCol+cOff, cache.StartRow+rOff, row); err != nil {
 			return err
 		}
+		g.recordFill(sheetName, config.RangeBounds{
+			StartCol: cache.StartCol + cOff, StartRow: cache.StartRow + rOff,
+			EndCol: cache.StartCol + cOff + cache.Width - 1, EndRow: cache.StartRow + rOff + cache.Height - 1,
+		})
 	}
 	return nil
 }
 
-// Helper to parse "A1:B2"
+// Helper to parse "A1:B2" (or a single cell "A1")
 func parseRange(ref string) (int, int, int, int, error) {
-	parts := strings.Split(ref, ":")
-	if len(parts) != 2 {
-		return 0, 0, 0, 0, fmt.Errorf("invalid range: %s", ref)
-	}
-	c1, r1, err := excelize.CellNameToCoordinates(parts[0])
-	if err != nil {
-		return 0, 0, 0, 0, err
-	}
-	c2, r2, err := excelize.CellNameToCoordinates(parts[1])
+	b, err := config.CellRange{Ref: ref}.Bounds()
 	if err != nil {
 		return 0, 0, 0, 0, err
 	}
-	return c1, r1, c2, r2, nil
+	return b.StartCol, b.StartRow, b.EndCol, b.EndRow, nil
 }
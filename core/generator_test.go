@@ -1,10 +1,13 @@
 package core
 
 import (
+	"context"
+	"errors"
 	"fibr-gen/config"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/xuri/excelize/v2"
@@ -217,6 +220,44 @@ func TestMatrixBlock_MergedHeader(t *testing.T) {
 	}
 }
 
+func TestMatrixBlock_RequiresExplicitUniqueHeaderDirections(t *testing.T) {
+	header := func(name string, direction config.Direction) config.BlockConfig {
+		return config.BlockConfig{Name: name, Type: config.BlockTypeHeader, Direction: direction, Range: config.CellRange{Ref: "A2:A2"}}
+	}
+	tests := []struct {
+		name    string
+		headers []config.BlockConfig
+		errMsg  string
+	}{
+		{
+			name:    "Empty direction",
+			headers: []config.BlockConfig{header("VAxis", ""), header("HAxis", config.DirectionHorizontal)},
+			errMsg:  "header block 'VAxis' must set direction",
+		},
+		{
+			name:    "Duplicate direction",
+			headers: []config.BlockConfig{header("VAxis", config.DirectionVertical), header("VAxis2", config.DirectionVertical), header("HAxis", config.DirectionHorizontal)},
+			errMsg:  "more than one vertical header block",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block := &config.BlockConfig{
+				Name:      "MatrixBlock",
+				Type:      config.BlockTypeMatrix,
+				Range:     config.CellRange{Ref: "A1:C2"},
+				SubBlocks: tt.headers,
+			}
+			ctx := NewGenerationContext(&config.WorkbookConfig{}, config.NewMemoryConfigRegistry(nil, nil), &MockFetcher{}, nil)
+			gen := NewGenerator(ctx)
+			err := gen.processBlock(&ExcelizeFile{file: excelize.NewFile()}, "Sheet1", block)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Fatalf("processBlock error = %v, want %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
 // Helper to create Demo Report Template
 func setupTemplateDemo(t *testing.T) *excelize.File {
 	f := excelize.NewFile()
@@ -844,3 +885,152 @@ func BenchmarkValueBlock_Insert50k(b *testing.B) {
 		}
 	}
 }
+
+func TestValueBlock_TypedLabels(t *testing.T) {
+	f := excelize.NewFile()
+	sheet := "Sheet1"
+	f.SetCellValue(sheet, "A1", "{name}")
+	f.SetCellValue(sheet, "B1", "{amount}")
+	f.SetCellValue(sheet, "C1", "{day}")
+	f.SetCellValue(sheet, "D1", "Paid on {day}")
+
+	block := config.BlockConfig{
+		Name:         "Payments",
+		Type:         config.BlockTypeValue,
+		Range:        config.CellRange{Ref: "A1:D1"},
+		DataViewName: "v_pay",
+	}
+	views := map[string]*config.DataViewConfig{
+		"v_pay": {
+			Name: "v_pay",
+			Labels: []config.LabelConfig{
+				{Name: "name", Column: "NAME", Type: config.LabelTypeString},
+				{Name: "amount", Column: "AMOUNT", Type: config.LabelTypeDecimal},
+				{Name: "day", Column: "DAY", Type: config.LabelTypeDate},
+			},
+		},
+	}
+	fetcher := &MockFetcher{Data: map[string][]map[string]interface{}{
+		"v_pay": {{"NAME": "Alice", "AMOUNT": "12.5", "DAY": "2025-03-14"}},
+	}}
+	ctx := NewGenerationContext(&config.WorkbookConfig{}, config.NewMemoryConfigRegistry(views, nil), fetcher, nil)
+	gen := NewGenerator(ctx)
+
+	if err := gen.processBlock(&ExcelizeFile{file: f}, sheet, &block); err != nil {
+		t.Fatalf("processBlock failed: %v", err)
+	}
+
+	if typ, _ := f.GetCellType(sheet, "A1"); typ != excelize.CellTypeSharedString {
+		t.Errorf("A1 type = %v, want shared string", typ)
+	}
+	for _, cell := range []string{"B1", "C1"} {
+		typ, _ := f.GetCellType(sheet, cell)
+		if typ == excelize.CellTypeSharedString || typ == excelize.CellTypeInlineString {
+			t.Errorf("%s should be a native cell, got string type", cell)
+		}
+	}
+	raw, _ := f.GetCellValue(sheet, "B1", excelize.Options{RawCellValue: true})
+	if raw != "12.5" {
+		t.Errorf("B1 raw = %s, want 12.5", raw)
+	}
+	val, _ := f.GetCellValue(sheet, "C1")
+	if val != "03-14-25" {
+		t.Errorf("C1 = %s, want date formatted 03-14-25", val)
+	}
+	raw, _ = f.GetCellValue(sheet, "C1", excelize.Options{RawCellValue: true})
+	if raw != "45730" {
+		t.Errorf("C1 raw = %s, want Excel serial 45730", raw)
+	}
+	val, _ = f.GetCellValue(sheet, "D1")
+	if val != "Paid on 2025-03-14" {
+		t.Errorf("D1 = %s, want Paid on 2025-03-14", val)
+	}
+}
+
+func TestValueBlock_TypedDateKeepsTemplateFormat(t *testing.T) {
+	f := excelize.NewFile()
+	sheet := "Sheet1"
+	f.SetCellValue(sheet, "A1", "{day}")
+	f.SetCellValue(sheet, "B1", "{day}")
+	f.SetCellValue(sheet, "C1", "{day}")
+	builtIn, _ := f.NewStyle(&excelize.Style{NumFmt: 15}) // d-mmm-yy
+	custom := "yyyy/mm/dd"
+	customStyle, _ := f.NewStyle(&excelize.Style{CustomNumFmt: &custom})
+	f.SetCellStyle(sheet, "A1", "A1", builtIn)
+	f.SetCellStyle(sheet, "B1", "B1", customStyle)
+
+	block := config.BlockConfig{
+		Name:         "Days",
+		Type:         config.BlockTypeValue,
+		Range:        config.CellRange{Ref: "A1:C1"},
+		DataViewName: "v_day",
+	}
+	views := map[string]*config.DataViewConfig{
+		"v_day": {Name: "v_day", Labels: []config.LabelConfig{{Name: "day", Column: "DAY", Type: config.LabelTypeDate}}},
+	}
+	fetcher := &MockFetcher{Data: map[string][]map[string]interface{}{
+		"v_day": {{"DAY": "2025-03-14"}},
+	}}
+	ctx := NewGenerationContext(&config.WorkbookConfig{}, config.NewMemoryConfigRegistry(views, nil), fetcher, nil)
+	if err := NewGenerator(ctx).processBlock(&ExcelizeFile{file: f}, sheet, &block); err != nil {
+		t.Fatalf("processBlock failed: %v", err)
+	}
+
+	for cell, want := range map[string]string{"A1": "14-Mar-25", "B1": "2025/03/14", "C1": "03-14-25"} {
+		if val, _ := f.GetCellValue(sheet, cell); val != want {
+			t.Errorf("%s = %s, want %s", cell, val, want)
+		}
+	}
+	styleID, _ := f.GetCellStyle(sheet, "A1")
+	if style, _ := f.GetStyle(styleID); style.NumFmt != 15 {
+		t.Errorf("A1 NumFmt = %d, want the template's 15", style.NumFmt)
+	}
+}
+
+func TestGenerateContext_Cancelled(t *testing.T) {
+	dir := t.TempDir()
+	f := setupTemplateValueBlock(t)
+	if err := f.SaveAs(filepath.Join(dir, "valueblock_template.xlsx")); err != nil {
+		t.Fatalf("save template: %v", err)
+	}
+
+	wbConfig := &config.WorkbookConfig{
+		Name:      "Cancelled",
+		Template:  "valueblock_template.xlsx",
+		OutputDir: "out",
+		Sheets: []config.SheetConfig{{
+			Name: "Sheet1",
+			Blocks: []config.BlockConfig{{
+				Name:         "EmployeeList",
+				Type:         config.BlockTypeValue,
+				Range:        config.CellRange{Ref: "A2:C2"},
+				DataViewName: "employee_view",
+			}},
+		}},
+	}
+	views := map[string]*config.DataViewConfig{
+		"employee_view": {Name: "employee_view", Labels: []config.LabelConfig{{Name: "name", Column: "USER_NAME"}}},
+	}
+	fetcher := &countingFetcher{data: map[string][]map[string]interface{}{
+		"employee_view": {{"USER_NAME": "Alice"}},
+	}}
+	gen := NewGenerator(NewGenerationContext(wbConfig, config.NewMemoryConfigRegistry(views, nil), fetcher, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := gen.GenerateContext(ctx, dir, dir)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("GenerateContext error = %v, want context.Canceled", err)
+	}
+	if fetcher.calls != 0 {
+		t.Fatalf("fetcher calls = %d, want 0", fetcher.calls)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out", "Cancelled.xlsx")); !os.IsNotExist(err) {
+		t.Fatalf("output saved despite cancellation (stat error: %v)", err)
+	}
+
+	// The generator is reusable with a live context.
+	if err := gen.GenerateContext(context.Background(), dir, dir); err != nil {
+		t.Fatalf("GenerateContext error: %v", err)
+	}
+}
@@ -0,0 +1,409 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fibr-gen/config"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultHttpTimeout      = 30 * time.Second
+	defaultHttpRetryBackoff = 500 * time.Millisecond
+	maxHttpRetryBackoff     = 10 * time.Second // unless the first delay is longer
+	defaultHttpMaxPages     = 1000
+)
+
+// HttpDataFetcher implements DataFetcher using REST endpoints that return JSON.
+// A view maps to <BaseURL>/<Path>, where Path defaults to the view's Table.
+// Params reach the endpoint only through the path, query and header templates.
+type HttpDataFetcher struct {
+	BaseURL  string
+	Options  *config.HttpSourceOptions // Optional: headers, pagination, timeout, retries
+	Client   *http.Client
+	Provider config.Provider // Optional: resolves view configs (Table, HTTP options)
+
+	retryBackoff time.Duration
+}
+
+// NewHttpDataFetcher creates a fetcher for the base URL, applying the timeout of opts.
+func NewHttpDataFetcher(baseURL string, opts *config.HttpSourceOptions) (*HttpDataFetcher, error) {
+	if opts == nil {
+		opts = &config.HttpSourceOptions{}
+	}
+	timeout, err := parseDurationOption(opts.Timeout, defaultHttpTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid http timeout: %w", err)
+	}
+	backoff, err := parseDurationOption(opts.RetryBackoff, defaultHttpRetryBackoff)
+	if err != nil {
+		return nil, fmt.Errorf("invalid http retryBackoff: %w", err)
+	}
+	return &HttpDataFetcher{
+		BaseURL:      baseURL,
+		Options:      opts,
+		Client:       &http.Client{Timeout: timeout, CheckRedirect: sameOriginRedirect},
+		retryBackoff: backoff,
+	}, nil
+}
+
+// sameOriginRedirect follows redirects only on the origin of the first request:
+// net/http drops Authorization and cookies on cross-domain redirects, but
+// would still send the configured headers, which may carry credentials.
+func sameOriginRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	if first := via[0].URL; !sameOrigin(req.URL, first) {
+		return fmt.Errorf("redirect to %s is not on %s://%s", req.URL.Redacted(), first.Scheme, first.Host)
+	}
+	return nil
+}
+
+// sameOrigin reports whether a and b have the same scheme and host (with port).
+func sameOrigin(a, b *url.URL) bool {
+	return a.Scheme == b.Scheme && a.Host == b.Host
+}
+
+func parseDurationOption(value string, def time.Duration) (time.Duration, error) {
+	if value == "" {
+		return def, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// Fetch requests every page of the view's endpoint and returns the rows found
+// at the view's Root, flattened like JsonDataFetcher rows.
+func (f *HttpDataFetcher) Fetch(viewName string, params map[string]string) ([]map[string]interface{}, error) {
+	return f.FetchContext(context.Background(), viewName, params)
+}
+
+// FetchContext is Fetch with requests and retry waits bound to ctx.
+func (f *HttpDataFetcher) FetchContext(ctx context.Context, viewName string, params map[string]string) ([]map[string]interface{}, error) {
+	conf, err := lookupViewConfig(f.Provider, viewName)
+	if err != nil {
+		return nil, err
+	}
+	var viewOpts config.HttpViewOptions
+	if conf != nil && conf.Http != nil {
+		viewOpts = *conf.Http
+	}
+	srcOpts := f.Options
+	if srcOpts == nil {
+		srcOpts = &config.HttpSourceOptions{}
+	}
+
+	endpoint, err := f.endpoint(viewName, conf, &viewOpts, params)
+	if err != nil {
+		return nil, err
+	}
+	headers := make(map[string]string, len(srcOpts.Headers))
+	for name, tmpl := range srcOpts.Headers {
+		if headers[name], err = expandTemplate(tmpl, params, nil); err != nil {
+			return nil, fmt.Errorf("http header '%s': %w", name, err)
+		}
+	}
+
+	pager := newHttpPager(srcOpts.Pagination)
+	var result []map[string]interface{}
+	next := pager.first(endpoint)
+	for page := 1; next != nil; page++ {
+		if page > pager.maxPages {
+			return nil, fmt.Errorf("data view '%s' exceeded %d pages", viewName, pager.maxPages)
+		}
+		body, header, err := f.get(ctx, next.String(), headers)
+		if err != nil {
+			return nil, err
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		var doc interface{}
+		if err := dec.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("failed to decode response of %s: %w", next.Redacted(), err)
+		}
+		node, err := jsonPath(doc, viewOpts.Root)
+		if err != nil {
+			return nil, fmt.Errorf("response of %s: %w", next.Redacted(), err)
+		}
+		rows, err := jsonRows(node)
+		if err != nil {
+			return nil, fmt.Errorf("response of %s: root '%s': %w", next.Redacted(), viewOpts.Root, err)
+		}
+		result = append(result, rows...)
+
+		nextURL, err := pager.next(next, len(rows), doc, header)
+		if err != nil {
+			return nil, fmt.Errorf("response of %s: %w", next.Redacted(), err)
+		}
+		next = nextURL
+	}
+	return result, nil
+}
+
+// endpoint builds the URL of the view from the base URL, path and query templates.
+func (f *HttpDataFetcher) endpoint(viewName string, conf *config.DataViewConfig, opts *config.HttpViewOptions, params map[string]string) (*url.URL, error) {
+	pathTmpl := opts.Path
+	if pathTmpl == "" {
+		pathTmpl = physicalName(viewName, conf)
+	}
+	path, err := expandTemplate(pathTmpl, params, url.PathEscape)
+	if err != nil {
+		return nil, fmt.Errorf("http path of view '%s': %w", viewName, err)
+	}
+
+	u, err := url.Parse(strings.TrimRight(f.BaseURL, "/") + "/" + strings.TrimLeft(path, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid http url for view '%s': %w", viewName, err)
+	}
+	query := u.Query()
+	for name, tmpl := range opts.Query {
+		value, err := expandTemplate(tmpl, params, nil)
+		if err != nil {
+			return nil, fmt.Errorf("http query '%s' of view '%s': %w", name, viewName, err)
+		}
+		query.Set(name, value)
+	}
+	u.RawQuery = query.Encode()
+	return u, nil
+}
+
+// httpStatusError reports a non-2xx response.
+type httpStatusError struct {
+	URL        string
+	StatusCode int
+	Body       string
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("GET %s: HTTP %d: %s", e.URL, e.StatusCode, e.Body)
+}
+
+// get performs a GET, retrying transport errors and 429 / 5xx responses with
+// exponential backoff, capped at maxHttpRetryBackoff.
+func (f *HttpDataFetcher) get(ctx context.Context, rawURL string, headers map[string]string) ([]byte, http.Header, error) {
+	retries := 0
+	if f.Options != nil {
+		retries = f.Options.Retries
+	}
+	backoff := f.retryBackoff
+	maxBackoff := max(f.retryBackoff, maxHttpRetryBackoff)
+	for attempt := 0; ; attempt++ {
+		body, header, err := f.do(ctx, rawURL, headers)
+		if err == nil || attempt >= retries || !isRetryableHttpError(err) {
+			return body, header, err
+		}
+		slog.Warn("Retrying HTTP request", "attempt", attempt+1, "error", err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+func (f *HttpDataFetcher) do(ctx context.Context, rawURL string, headers map[string]string) ([]byte, http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response of %s: %w", req.URL.Redacted(), err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet := string(body)
+		if len(snippet) > 200 {
+			snippet = snippet[:200] + "..."
+		}
+		return nil, nil, &httpStatusError{URL: req.URL.Redacted(), StatusCode: resp.StatusCode, Body: snippet}
+	}
+	return body, resp.Header, nil
+}
+
+func isRetryableHttpError(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+	}
+	return true // transport errors and timeouts
+}
+
+// httpPager computes the URL of the next page for a pagination style.
+type httpPager struct {
+	conf     config.HttpPagination
+	maxPages int
+	position int // page number or row offset of the current page
+}
+
+func newHttpPager(conf *config.HttpPagination) *httpPager {
+	p := &httpPager{}
+	if conf != nil {
+		p.conf = *conf
+	}
+	p.maxPages = p.conf.MaxPages
+	if p.maxPages == 0 {
+		p.maxPages = defaultHttpMaxPages
+	}
+	return p
+}
+
+// first returns the URL of the first page.
+func (p *httpPager) first(endpoint *url.URL) *url.URL {
+	switch p.conf.Style {
+	case config.PaginationPage:
+		p.position = 1
+		return p.withQuery(endpoint, p.param("page"), strconv.Itoa(p.position))
+	case config.PaginationOffset:
+		p.position = 0
+		return p.withQuery(endpoint, p.param("offset"), "0")
+	default:
+		return p.withQuery(endpoint, "", "")
+	}
+}
+
+// next returns the URL of the page after current, or nil when current was the last.
+func (p *httpPager) next(current *url.URL, rows int, doc interface{}, header http.Header) (*url.URL, error) {
+	switch p.conf.Style {
+	case config.PaginationPage, config.PaginationOffset:
+		if rows == 0 || (p.conf.PageSize > 0 && rows < p.conf.PageSize) {
+			return nil, nil
+		}
+		if p.conf.Style == config.PaginationPage {
+			p.position++
+			return p.withQuery(current, p.param("page"), strconv.Itoa(p.position)), nil
+		}
+		p.position += rows
+		return p.withQuery(current, p.param("offset"), strconv.Itoa(p.position)), nil
+
+	case config.PaginationCursor:
+		node, err := jsonPath(doc, p.conf.CursorPath)
+		if err != nil || node == nil {
+			return nil, nil // no cursor: last page
+		}
+		cursor := fmt.Sprintf("%v", node)
+		if cursor == "" {
+			return nil, nil
+		}
+		name := p.conf.CursorParam
+		if name == "" {
+			name = "cursor"
+		}
+		return p.withQuery(current, name, cursor), nil
+
+	case config.PaginationLink:
+		link := nextLink(header.Values("Link"))
+		if link == "" {
+			return nil, nil
+		}
+		ref, err := url.Parse(link)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Link header url '%s': %w", link, err)
+		}
+		// The configured headers (credentials) are sent to the next page too:
+		// never follow a link to another origin.
+		next := current.ResolveReference(ref)
+		if !sameOrigin(next, current) {
+			return nil, fmt.Errorf("next page url %s of the Link header is not on %s://%s", next.Redacted(), current.Scheme, current.Host)
+		}
+		return next, nil
+
+	default:
+		return nil, nil
+	}
+}
+
+// param returns the configured page / offset query param or its default.
+func (p *httpPager) param(def string) string {
+	if p.conf.PageParam != "" {
+		return p.conf.PageParam
+	}
+	return def
+}
+
+// withQuery copies u, setting the page size param and, if name is set, name=value.
+func (p *httpPager) withQuery(u *url.URL, name, value string) *url.URL {
+	next := *u
+	query := next.Query()
+	if p.conf.PageSize > 0 {
+		sizeParam := p.conf.SizeParam
+		if sizeParam == "" {
+			sizeParam = "limit"
+		}
+		query.Set(sizeParam, strconv.Itoa(p.conf.PageSize))
+	}
+	if name != "" {
+		query.Set(name, value)
+	}
+	next.RawQuery = query.Encode()
+	return &next
+}
+
+var linkNextRe = regexp.MustCompile(`<([^>]*)>\s*;[^,]*\brel="?next"?`)
+
+// nextLink returns the rel="next" target of RFC 8288 Link header values.
+func nextLink(values []string) string {
+	for _, value := range values {
+		if m := linkNextRe.FindStringSubmatch(value); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+var templateVarRe = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// expandTemplate substitutes ${param} and ${env:NAME} references, passing
+// substituted values through escape when set. Missing params and unset
+// environment variables are errors.
+func expandTemplate(tmpl string, params map[string]string, escape func(string) string) (string, error) {
+	var missing []string
+	out := templateVarRe.ReplaceAllStringFunc(tmpl, func(m string) string {
+		name := m[2 : len(m)-1]
+		var value string
+		var ok bool
+		if env, isEnv := strings.CutPrefix(name, "env:"); isEnv {
+			value, ok = os.LookupEnv(env)
+		} else {
+			value, ok = params[name]
+		}
+		if !ok {
+			missing = append(missing, name)
+			return m
+		}
+		if escape != nil {
+			value = escape(value)
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("template '%s' references undefined %s", tmpl, strings.Join(missing, ", "))
+	}
+	return out, nil
+}
@@ -0,0 +1,222 @@
+package core
+
+import (
+	"fibr-gen/config"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestHttpDataFetcher_PagePaginationAuthAndRetry(t *testing.T) {
+	t.Setenv("FIBR_TEST_TOKEN", "s3cret")
+
+	failures := 1
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/depts/R&D 1/employees" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer s3cret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		if r.URL.Query().Get("month") != "2025-01" || r.URL.Query().Get("per_page") != "2" {
+			t.Errorf("query = %s", r.URL.RawQuery)
+		}
+		if failures > 0 {
+			failures--
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		switch r.URL.Query().Get("page") {
+		case "1":
+			fmt.Fprint(w, `{"data": [{"id": 1, "name": {"first": "Alice"}}, {"id": 2, "name": {"first": "Bob"}}]}`)
+		case "2":
+			fmt.Fprint(w, `{"data": [{"id": 3, "name": {"first": "Carol"}}]}`)
+		default:
+			t.Errorf("unexpected page %s", r.URL.Query().Get("page"))
+		}
+	}))
+	defer srv.Close()
+
+	fetcher, err := NewHttpDataFetcher(srv.URL+"/v1", &config.HttpSourceOptions{
+		Headers:      map[string]string{"Authorization": "Bearer ${env:FIBR_TEST_TOKEN}"},
+		Pagination:   &config.HttpPagination{Style: config.PaginationPage, SizeParam: "per_page", PageSize: 2},
+		Retries:      2,
+		RetryBackoff: "1ms",
+	})
+	if err != nil {
+		t.Fatalf("NewHttpDataFetcher error: %v", err)
+	}
+	views := map[string]*config.DataViewConfig{
+		"v_emp": {Name: "v_emp", Http: &config.HttpViewOptions{
+			Path:  "depts/${dept}/employees",
+			Query: map[string]string{"month": "${month}"},
+			Root:  "data",
+		}},
+	}
+	fetcher.Provider = config.NewMemoryConfigRegistry(views, nil)
+
+	rows, err := fetcher.Fetch("v_emp", map[string]string{"dept": "R&D 1", "month": "2025-01"})
+	if err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	if len(rows) != 3 || rows[2]["name.first"] != "Carol" || rows[2]["id"] != int64(3) {
+		t.Fatalf("rows = %v, want 3 rows ending with Carol", rows)
+	}
+
+	if _, err := fetcher.Fetch("v_emp", map[string]string{"dept": "R&D 1"}); err == nil {
+		t.Fatal("expected error for missing query param")
+	}
+}
+
+func TestHttpDataFetcher_CursorAndLinkPagination(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cursor":
+			switch r.URL.Query().Get("after") {
+			case "":
+				fmt.Fprint(w, `{"items": [{"n": 1}], "meta": {"next": "abc"}}`)
+			case "abc":
+				fmt.Fprint(w, `{"items": [{"n": 2}], "meta": {"next": null}}`)
+			}
+		case "/link":
+			page, _ := strconv.Atoi(r.URL.Query().Get("p"))
+			if page < 2 {
+				w.Header().Set("Link", fmt.Sprintf(`</link?p=%d>; rel="next", </link?p=2>; rel="last"`, page+1))
+			}
+			fmt.Fprintf(w, `[{"n": %d}]`, page)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		pagination *config.HttpPagination
+		view       *config.HttpViewOptions
+		want       int
+	}{
+		{
+			name:       "Cursor",
+			pagination: &config.HttpPagination{Style: config.PaginationCursor, CursorParam: "after", CursorPath: "meta.next"},
+			view:       &config.HttpViewOptions{Path: "cursor", Root: "items"},
+			want:       2,
+		},
+		{
+			name:       "Link header",
+			pagination: &config.HttpPagination{Style: config.PaginationLink},
+			view:       &config.HttpViewOptions{Path: "link"},
+			want:       3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher, err := NewHttpDataFetcher(srv.URL, &config.HttpSourceOptions{Pagination: tt.pagination})
+			if err != nil {
+				t.Fatalf("NewHttpDataFetcher error: %v", err)
+			}
+			fetcher.Provider = config.NewMemoryConfigRegistry(map[string]*config.DataViewConfig{
+				"v": {Name: "v", Http: tt.view},
+			}, nil)
+
+			rows, err := fetcher.Fetch("v", nil)
+			if err != nil {
+				t.Fatalf("Fetch error: %v", err)
+			}
+			if len(rows) != tt.want {
+				t.Fatalf("rows = %v, want %d rows", rows, tt.want)
+			}
+		})
+	}
+}
+
+func TestHttpDataFetcher_DoesNotRetryClientErrors(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	fetcher, err := NewHttpDataFetcher(srv.URL, &config.HttpSourceOptions{Retries: 3, RetryBackoff: "1ms"})
+	if err != nil {
+		t.Fatalf("NewHttpDataFetcher error: %v", err)
+	}
+	if _, err := fetcher.Fetch("v", nil); err == nil {
+		t.Fatal("expected error for HTTP 403")
+	}
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1", calls)
+	}
+}
+
+func TestHttpDataFetcher_LinkPaginationStaysOnOrigin(t *testing.T) {
+	leaked := false
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaked = r.Header.Get("Authorization") != ""
+		fmt.Fprint(w, `[]`)
+	}))
+	defer other.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", fmt.Sprintf(`<%s/steal?p=2>; rel="next"`, other.URL))
+		fmt.Fprint(w, `[{"n": 1}]`)
+	}))
+	defer srv.Close()
+
+	fetcher, err := NewHttpDataFetcher(srv.URL, &config.HttpSourceOptions{
+		Headers:    map[string]string{"Authorization": "Bearer s3cret"},
+		Pagination: &config.HttpPagination{Style: config.PaginationLink},
+	})
+	if err != nil {
+		t.Fatalf("NewHttpDataFetcher error: %v", err)
+	}
+	if _, err := fetcher.Fetch("v", nil); err == nil || !strings.Contains(err.Error(), "is not on") {
+		t.Fatalf("Fetch error = %v, want a refused cross-origin Link", err)
+	}
+	if leaked {
+		t.Fatal("credentials were sent to another origin")
+	}
+}
+
+func TestHttpDataFetcher_RedirectsStayOnOrigin(t *testing.T) {
+	leaked := false
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaked = r.Header.Get("X-Api-Key") != ""
+		fmt.Fprint(w, `[]`)
+	}))
+	defer other.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moved":
+			http.Redirect(w, r, "/v", http.StatusFound)
+		case "/v":
+			fmt.Fprintf(w, `[{"key": %q}]`, r.Header.Get("X-Api-Key"))
+		default:
+			http.Redirect(w, r, other.URL+"/steal", http.StatusFound)
+		}
+	}))
+	defer srv.Close()
+
+	fetcher, err := NewHttpDataFetcher(srv.URL, &config.HttpSourceOptions{
+		Headers: map[string]string{"X-Api-Key": "s3cret"},
+	})
+	if err != nil {
+		t.Fatalf("NewHttpDataFetcher error: %v", err)
+	}
+
+	rows, err := fetcher.Fetch("moved", nil)
+	if err != nil {
+		t.Fatalf("Fetch same-origin redirect error: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["key"] != "s3cret" {
+		t.Fatalf("rows = %v, want the redirect target's row", rows)
+	}
+
+	if _, err := fetcher.Fetch("elsewhere", nil); err == nil || !strings.Contains(err.Error(), "is not on") {
+		t.Fatalf("Fetch error = %v, want a refused cross-origin redirect", err)
+	}
+	if leaked {
+		t.Fatal("headers were sent to another origin")
+	}
+}
@@ -0,0 +1,188 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fibr-gen/config"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// JsonDataFetcher implements DataFetcher using JSON or newline-delimited JSON files.
+// It maps a view to <RootDir>/<Table>.json, where Table defaults to the view name,
+// or to the files matching the Path of its JSON options.
+// Nested objects are flattened into dotted column names ("customer.address.city").
+type JsonDataFetcher struct {
+	RootDir  string
+	Provider config.Provider // Optional: resolves view configs (Table, JSON options)
+}
+
+func NewJsonDataFetcher(rootDir string) *JsonDataFetcher {
+	return &JsonDataFetcher{RootDir: rootDir}
+}
+
+func (f *JsonDataFetcher) Fetch(viewName string, params map[string]string) ([]map[string]interface{}, error) {
+	conf, err := lookupViewConfig(f.Provider, viewName)
+	if err != nil {
+		return nil, err
+	}
+	var opts config.JsonOptions
+	if conf != nil && conf.Json != nil {
+		opts = *conf.Json
+	}
+
+	paths, err := resolveViewFiles(f.RootDir, viewName, conf, opts.Path, ".json", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []map[string]interface{}
+	for _, path := range paths {
+		rows, err := readJsonFile(path, &opts)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, filterRows(rows, conf, params)...)
+	}
+	return result, nil
+}
+
+// readJsonFile decodes the rows of a JSON document (located by opts.Root) or
+// of an NDJSON file (one object per line).
+func readJsonFile(path string, opts *config.JsonOptions) ([]map[string]interface{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open json file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	format := opts.Format
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".ndjson", ".jsonl":
+			format = "ndjson"
+		default:
+			format = "json"
+		}
+	}
+
+	dec := json.NewDecoder(file)
+	dec.UseNumber()
+
+	var rows []map[string]interface{}
+	if format == "ndjson" {
+		for line := 1; ; line++ {
+			var doc interface{}
+			if err := dec.Decode(&doc); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return nil, fmt.Errorf("failed to decode %s record %d: %w", path, line, err)
+			}
+			obj, ok := doc.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s record %d is not an object", path, line)
+			}
+			rows = append(rows, flattenJson(obj))
+		}
+		return rows, nil
+	}
+
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	node, err := jsonPath(doc, opts.Root)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	rows, err = jsonRows(node)
+	if err != nil {
+		return nil, fmt.Errorf("%s: root '%s': %w", path, opts.Root, err)
+	}
+	return rows, nil
+}
+
+// jsonRows flattens the rows of a decoded node: an array of objects, a single
+// object (one row) or null (no rows).
+func jsonRows(node interface{}) ([]map[string]interface{}, error) {
+	var rows []map[string]interface{}
+	switch v := node.(type) {
+	case []interface{}:
+		for i, elem := range v {
+			obj, ok := elem.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("row %d is not an object", i)
+			}
+			rows = append(rows, flattenJson(obj))
+		}
+	case map[string]interface{}:
+		rows = append(rows, flattenJson(v))
+	case nil:
+		// No rows
+	default:
+		return nil, fmt.Errorf("neither an array nor an object")
+	}
+	return rows, nil
+}
+
+// jsonPath walks a dotted path of object keys and array indexes ("data.items",
+// "results.0.rows"). An empty path returns the document itself.
+func jsonPath(doc interface{}, path string) (interface{}, error) {
+	if path == "" {
+		return doc, nil
+	}
+	node := doc
+	for _, key := range strings.Split(path, ".") {
+		switch v := node.(type) {
+		case map[string]interface{}:
+			next, ok := v[key]
+			if !ok {
+				return nil, fmt.Errorf("path '%s': key '%s' not found", path, key)
+			}
+			node = next
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, fmt.Errorf("path '%s': invalid array index '%s'", path, key)
+			}
+			node = v[i]
+		default:
+			return nil, fmt.Errorf("path '%s': cannot descend into '%s'", path, key)
+		}
+	}
+	return node, nil
+}
+
+// flattenJson turns nested objects into dotted column names. Arrays are kept as values.
+// Numbers become int64 when integral, float64 otherwise.
+func flattenJson(obj map[string]interface{}) map[string]interface{} {
+	row := make(map[string]interface{}, len(obj))
+	var walk func(prefix string, v interface{})
+	walk = func(prefix string, v interface{}) {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			for k, child := range val {
+				walk(prefix+"."+k, child)
+			}
+		case json.Number:
+			if i, err := val.Int64(); err == nil {
+				row[prefix] = i
+			} else if f, err := val.Float64(); err == nil {
+				row[prefix] = f
+			} else {
+				row[prefix] = val.String()
+			}
+		default:
+			row[prefix] = val
+		}
+	}
+	for k, v := range obj {
+		walk(k, v)
+	}
+	return row
+}
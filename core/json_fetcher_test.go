@@ -0,0 +1,94 @@
+package core
+
+import (
+	"fibr-gen/config"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJsonDataFetcher_FetchNestedDocument(t *testing.T) {
+	dir := t.TempDir()
+	content := `{"data": {"items": [
+		{"id": 1, "dept": "D1", "owner": {"name": "Alice", "address": {"city": "Paris"}}, "score": 9.5},
+		{"id": 2, "dept": "D2", "owner": {"name": "Bob", "address": {"city": "Tokyo"}}, "tags": ["a", "b"]}
+	]}}`
+	if err := os.WriteFile(filepath.Join(dir, "export.json"), []byte(content), 0644); err != nil {
+		t.Fatalf("write json: %v", err)
+	}
+
+	views := map[string]*config.DataViewConfig{
+		"v_owners": {Name: "v_owners", Table: "export", Json: &config.JsonOptions{Root: "data.items"}, Labels: []config.LabelConfig{
+			{Name: "owner", Column: "owner.name"},
+			{Name: "id", Column: "id"},
+		}},
+	}
+	fetcher := NewJsonDataFetcher(dir)
+	fetcher.Provider = config.NewMemoryConfigRegistry(views, nil)
+
+	rows, err := fetcher.Fetch("v_owners", nil)
+	if err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("rows = %d, want 2", len(rows))
+	}
+	if rows[0]["owner.address.city"] != "Paris" || rows[0]["id"] != int64(1) || rows[0]["score"] != 9.5 {
+		t.Errorf("row 0 = %v", rows[0])
+	}
+
+	rows, err = fetcher.Fetch("v_owners", map[string]string{"owner.name": "Bob", "id": "2", "score": "9.5"})
+	if err != nil {
+		t.Fatalf("Fetch filtered error: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["owner.address.city"] != "Tokyo" {
+		t.Fatalf("filtered rows = %v, want Bob's row", rows)
+	}
+}
+
+func TestJsonDataFetcher_FetchNdjson(t *testing.T) {
+	dir := t.TempDir()
+	content := "{\"dept\": \"D1\", \"name\": \"Alice\"}\n\n{\"dept\": \"D2\", \"name\": \"Bob\"}\n"
+	if err := os.WriteFile(filepath.Join(dir, "staff_2025-01.ndjson"), []byte(content), 0644); err != nil {
+		t.Fatalf("write ndjson: %v", err)
+	}
+
+	views := map[string]*config.DataViewConfig{
+		"v_staff": {Name: "v_staff", Json: &config.JsonOptions{Path: "staff_${month}.ndjson"}, Labels: []config.LabelConfig{
+			{Name: "dept", Column: "dept"},
+		}},
+	}
+	fetcher := NewJsonDataFetcher(dir)
+	fetcher.Provider = config.NewMemoryConfigRegistry(views, nil)
+
+	rows, err := fetcher.Fetch("v_staff", map[string]string{"month": "2025-01", "dept": "D2"})
+	if err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["name"] != "Bob" {
+		t.Fatalf("rows = %v, want Bob", rows)
+	}
+}
+
+func TestJsonPath(t *testing.T) {
+	doc := map[string]interface{}{
+		"results": []interface{}{
+			map[string]interface{}{"rows": []interface{}{"x"}},
+		},
+	}
+	tests := []struct {
+		path    string
+		wantErr bool
+	}{
+		{"", false},
+		{"results.0.rows", false},
+		{"results.1.rows", true},
+		{"results.0.missing", true},
+		{"results.0.rows.0.deeper", true},
+	}
+	for _, tt := range tests {
+		if _, err := jsonPath(doc, tt.path); (err != nil) != tt.wantErr {
+			t.Errorf("jsonPath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package core
+
+import (
+	"fibr-gen/config"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Layouts accepted when coercing text to date / datetime labels.
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+	"20060102",
+}
+
+// CoerceLabelValue converts a fetched value to the Go type of a label type:
+// int -> int64, decimal -> float64, date / datetime -> time.Time, bool -> bool,
+// string -> string. An empty type returns the value unchanged.
+// Nil values and blank strings coerce to nil for non-string types.
+func CoerceLabelValue(t config.LabelType, v interface{}) (interface{}, error) {
+	if t == "" || v == nil {
+		return v, nil
+	}
+	if b, ok := v.([]byte); ok {
+		v = string(b)
+	}
+	if t == config.LabelTypeString {
+		return formatLabelValue("", v), nil
+	}
+	if s, ok := v.(string); ok {
+		v = strings.TrimSpace(s)
+		if v == "" {
+			return nil, nil
+		}
+	}
+
+	switch t {
+	case config.LabelTypeInt:
+		return coerceInt(v)
+	case config.LabelTypeDecimal:
+		return coerceFloat(v)
+	case config.LabelTypeDate, config.LabelTypeDateTime:
+		return coerceTime(v)
+	case config.LabelTypeBool:
+		return coerceBool(v)
+	default:
+		return nil, fmt.Errorf("unsupported label type '%s'", t)
+	}
+}
+
+// formatLabelValue renders a (coerced) label value as text.
+func formatLabelValue(t config.LabelType, v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	if tm, ok := v.(time.Time); ok {
+		if t == config.LabelTypeDate {
+			return tm.Format("2006-01-02")
+		}
+		return tm.Format("2006-01-02 15:04:05")
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func coerceInt(v interface{}) (interface{}, error) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), nil
+	case int8:
+		return int64(n), nil
+	case int16:
+		return int64(n), nil
+	case int32:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	case uint:
+		return coerceUint(uint64(n))
+	case uint8:
+		return int64(n), nil
+	case uint16:
+		return int64(n), nil
+	case uint32:
+		return int64(n), nil
+	case uint64:
+		return coerceUint(n)
+	case bool:
+		if n {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	}
+
+	f, err := coerceFloat(v)
+	if err != nil {
+		return nil, err
+	}
+	fv := f.(float64)
+	if fv != math.Trunc(fv) {
+		return nil, fmt.Errorf("value %v is not an integer", v)
+	}
+	if fv < math.MinInt64 || fv >= math.MaxInt64 {
+		return nil, fmt.Errorf("value %v is out of the int64 range", v)
+	}
+	return int64(fv), nil
+}
+
+func coerceUint(n uint64) (interface{}, error) {
+	if n > math.MaxInt64 {
+		return nil, fmt.Errorf("value %d is out of the int64 range", n)
+	}
+	return int64(n), nil
+}
+
+func coerceFloat(v interface{}) (interface{}, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case float32:
+		return float64(n), nil
+	case int:
+		return float64(n), nil
+	case int32:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	}
+	// Other numeric kinds (and CSV / DynamoDB text) go through their text form.
+	f, err := strconv.ParseFloat(fmt.Sprintf("%v", v), 64)
+	if err != nil {
+		return nil, fmt.Errorf("value %v is not a number", v)
+	}
+	return f, nil
+}
+
+func coerceTime(v interface{}) (interface{}, error) {
+	if tm, ok := v.(time.Time); ok {
+		return tm, nil
+	}
+	s := fmt.Sprintf("%v", v)
+	for _, layout := range dateLayouts {
+		if tm, err := time.Parse(layout, s); err == nil {
+			return tm, nil
+		}
+	}
+	return nil, fmt.Errorf("value %v is not a date", v)
+}
+
+func coerceBool(v interface{}) (interface{}, error) {
+	if b, ok := v.(bool); ok {
+		return b, nil
+	}
+	switch strings.ToLower(fmt.Sprintf("%v", v)) {
+	case "1", "t", "true", "y", "yes":
+		return true, nil
+	case "0", "f", "false", "n", "no":
+		return false, nil
+	}
+	return nil, fmt.Errorf("value %v is not a boolean", v)
+}
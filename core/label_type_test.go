@@ -0,0 +1,50 @@
+package core
+
+import (
+	"fibr-gen/config"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCoerceLabelValue(t *testing.T) {
+	day := time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		typ     config.LabelType
+		in      interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "Untyped passthrough", typ: "", in: "5000", want: "5000"},
+		{name: "String from int", typ: config.LabelTypeString, in: 42, want: "42"},
+		{name: "Int from CSV text", typ: config.LabelTypeInt, in: " 5000 ", want: int64(5000)},
+		{name: "Int from DynamoDB number", typ: config.LabelTypeInt, in: float64(2025), want: int64(2025)},
+		{name: "Int from uint64", typ: config.LabelTypeInt, in: uint64(1 << 40), want: int64(1 << 40)},
+		{name: "Int rejects uint64 overflow", typ: config.LabelTypeInt, in: uint64(1 << 63), wantErr: true},
+		{name: "Int rejects text overflow", typ: config.LabelTypeInt, in: "1e19", wantErr: true},
+		{name: "Int rejects fraction", typ: config.LabelTypeInt, in: "1.5", wantErr: true},
+		{name: "Decimal from text", typ: config.LabelTypeDecimal, in: "12.75", want: 12.75},
+		{name: "Decimal from bytes", typ: config.LabelTypeDecimal, in: []byte("3.5"), want: 3.5},
+		{name: "Decimal rejects text", typ: config.LabelTypeDecimal, in: "abc", wantErr: true},
+		{name: "Date from text", typ: config.LabelTypeDate, in: "2025-03-14", want: day},
+		{name: "Datetime from text", typ: config.LabelTypeDateTime, in: "2025-03-14 08:30:00", want: day.Add(8*time.Hour + 30*time.Minute)},
+		{name: "Date passthrough", typ: config.LabelTypeDate, in: day, want: day},
+		{name: "Bool from text", typ: config.LabelTypeBool, in: "yes", want: true},
+		{name: "Bool from int", typ: config.LabelTypeBool, in: 0, want: false},
+		{name: "Blank text is nil", typ: config.LabelTypeInt, in: "", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CoerceLabelValue(tt.typ, tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CoerceLabelValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CoerceLabelValue() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
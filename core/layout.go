@@ -0,0 +1,274 @@
+package core
+
+import (
+	"fibr-gen/config"
+	"fmt"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// layoutInsert records rows (or columns) inserted by an expanding block.
+type layoutInsert struct {
+	rows      bool
+	at, count int // count rows inserted before row (column) at
+}
+
+// shift moves a row (column) number past the insertion.
+func (ins layoutInsert) shift(n int) int {
+	if n >= ins.at {
+		return n + ins.count
+	}
+	return n
+}
+
+func (ins layoutInsert) move(r config.RangeBounds) config.RangeBounds {
+	if ins.rows {
+		r.StartRow, r.EndRow = ins.shift(r.StartRow), ins.shift(r.EndRow)
+	} else {
+		r.StartCol, r.EndCol = ins.shift(r.StartCol), ins.shift(r.EndCol)
+	}
+	return r
+}
+
+// placedBlock is the area of the sheet a processed block filled.
+type placedBlock struct {
+	name   string
+	extent config.RangeBounds
+}
+
+// sheetLayout tracks the rows and columns inserted by expanding blocks of a
+// sheet, so that the blocks configured below or right of them, whose ranges
+// refer to the template, are filled where their cells moved to.
+//
+// Blocks side by side that expand from the same row share the inserted rows
+// rather than each inserting its own (likewise for columns). With
+// VerticalArrangement the blocks are stacked, so horizontal expansions write
+// into the existing columns instead of inserting columns through the blocks
+// above and below. Unless AllowOverlap is set, a block whose range or data
+// runs into the area filled by another block, or over template cells, is an
+// error.
+type sheetLayout struct {
+	vertical     bool
+	allowOverlap bool
+
+	blocks  []config.BlockConfig // top-level blocks of the sheet, laid out in order
+	next    int                  // number of blocks begun
+	inserts []layoutInsert
+	placed  []placedBlock
+	current *placedBlock // block being processed
+}
+
+func newSheetLayout(conf *config.SheetConfig) *sheetLayout {
+	return &sheetLayout{vertical: conf.VerticalArrangement, allowOverlap: conf.AllowOverlap, blocks: conf.Blocks}
+}
+
+// relocate returns a copy of block whose range and sub-block ranges are moved
+// by the insertions made so far.
+func (l *sheetLayout) relocate(block *config.BlockConfig) *config.BlockConfig {
+	moved := *block
+	moved.Range = config.CellRange{Ref: l.relocateRef(block.Range.Ref)}
+	if len(block.SubBlocks) > 0 {
+		moved.SubBlocks = make([]config.BlockConfig, len(block.SubBlocks))
+		for i := range block.SubBlocks {
+			moved.SubBlocks[i] = *l.relocate(&block.SubBlocks[i])
+		}
+	}
+	return &moved
+}
+
+func (l *sheetLayout) relocateRef(ref string) string {
+	r, err := config.CellRange{Ref: ref}.Bounds()
+	if err != nil {
+		return ref // Reported by the block itself
+	}
+	for _, ins := range l.inserts {
+		r = ins.move(r)
+	}
+	return r.String()
+}
+
+// reserve returns where and how many of count rows (columns) wanted before
+// row (column) at are to be inserted: rows inserted at the same place by a
+// block alongside are reused, and columns are never inserted in a vertical
+// arrangement. Rows are not inserted through a block alongside whose area
+// spans the insertion point, as that would split its template or data: they
+// are inserted below it, and the existing rows down to there are filled
+// instead, provided that hasContent reports no template content in them for
+// the current block's columns (unless AllowOverlap is set).
+func (l *sheetLayout) reserve(rows bool, at, count int, hasContent func(col, row int) bool) (int, int, error) {
+	if !rows && l.vertical {
+		return at, 0, nil
+	}
+	for count > 0 {
+		shared := l.shared(rows, at)
+		at, count = at+shared, count-shared
+		below := l.below(rows, at)
+		if below == at || count <= 0 {
+			break
+		}
+		if err := l.checkReuse(rows, at, min(below, at+count), hasContent); err != nil {
+			return 0, 0, err
+		}
+		at, count = below, count-(below-at)
+	}
+	return at, max(count, 0), nil
+}
+
+// shared returns how many rows (columns) inserted by earlier blocks directly
+// follow row (column) at.
+func (l *sheetLayout) shared(rows bool, at int) int {
+	shared := 0
+	for i, ins := range l.inserts {
+		if ins.rows != rows {
+			continue
+		}
+		pos := ins.at
+		for _, later := range l.inserts[i+1:] {
+			if later.rows == rows {
+				pos = later.shift(pos)
+			}
+		}
+		if pos == at+shared {
+			shared += ins.count
+		}
+	}
+	return shared
+}
+
+// below returns the first row (column) at or after at that is not inside the
+// area of another block: a placed block's filled area or a pending block's
+// (relocated) template range.
+func (l *sheetLayout) below(rows bool, at int) int {
+	areas := make([]config.RangeBounds, 0, len(l.placed)+len(l.blocks))
+	for _, p := range l.placed {
+		areas = append(areas, p.extent)
+	}
+	for _, block := range l.blocks[min(l.next, len(l.blocks)):] {
+		if r, err := (config.CellRange{Ref: l.relocateRef(block.Range.Ref)}).Bounds(); err == nil {
+			areas = append(areas, r)
+		}
+	}
+	for moved := true; moved; {
+		moved = false
+		for _, r := range areas {
+			start, end := r.StartCol, r.EndCol
+			if rows {
+				start, end = r.StartRow, r.EndRow
+			}
+			if start < at && at <= end {
+				at, moved = end+1, true
+			}
+		}
+	}
+	return at
+}
+
+// checkReuse checks the existing rows (columns) from..to-1 that the current
+// block expands into.
+func (l *sheetLayout) checkReuse(rows bool, from, to int, hasContent func(col, row int) bool) error {
+	if l.current == nil || from >= to {
+		return nil
+	}
+	area := l.current.extent
+	if rows {
+		area.StartRow, area.EndRow = from, to-1
+	} else {
+		area.StartCol, area.EndCol = from, to-1
+	}
+	return l.checkArea(area, hasContent)
+}
+
+// checkExpansion reports the template content that count columns written
+// from column at would overwrite, in a vertical arrangement where a block
+// expanding horizontally writes into the existing columns: any cell of the
+// current block's rows for which hasContent is true is an overlap, unless
+// AllowOverlap is set.
+func (l *sheetLayout) checkExpansion(at, count int, hasContent func(col, row int) bool) error {
+	if !l.vertical || l.current == nil || count <= 0 {
+		return nil
+	}
+	return l.checkArea(config.RangeBounds{
+		StartCol: at, StartRow: l.current.extent.StartRow,
+		EndCol: at + count - 1, EndRow: l.current.extent.EndRow,
+	}, hasContent)
+}
+
+// checkArea reports the first template cell of area the current block would
+// overwrite, unless AllowOverlap is set.
+func (l *sheetLayout) checkArea(area config.RangeBounds, hasContent func(col, row int) bool) error {
+	if l.allowOverlap || hasContent == nil {
+		return nil
+	}
+	for row := area.StartRow; row <= area.EndRow; row++ {
+		for col := area.StartCol; col <= area.EndCol; col++ {
+			if hasContent(col, row) {
+				cell, _ := excelize.CoordinatesToCellName(col, row)
+				return fmt.Errorf("block '%s' expanding over %s overwrites template cell %s (set allowOverlap on the sheet to permit this)",
+					l.current.name, area, cell)
+			}
+		}
+	}
+	return nil
+}
+
+// record registers count rows (columns) inserted before row (column) at.
+func (l *sheetLayout) record(rows bool, at, count int) {
+	ins := layoutInsert{rows: rows, at: at, count: count}
+	l.inserts = append(l.inserts, ins)
+	for i := range l.placed {
+		l.placed[i].extent = ins.move(l.placed[i].extent)
+	}
+	if l.current != nil {
+		l.current.extent = ins.move(l.current.extent)
+	}
+}
+
+// begin starts laying out a relocated block.
+func (l *sheetLayout) begin(block *config.BlockConfig) error {
+	l.next++
+	r, err := block.Range.Bounds()
+	if err != nil {
+		l.current = nil
+		return nil
+	}
+	if err := l.checkOverlap(block.Name, r); err != nil {
+		return err
+	}
+	l.current = &placedBlock{name: block.Name, extent: r}
+	return nil
+}
+
+// fill extends the current block's area by cells it wrote.
+func (l *sheetLayout) fill(r config.RangeBounds) {
+	if l.current != nil {
+		l.current.extent = l.current.extent.Union(r)
+	}
+}
+
+// end finishes the current block, checking that its data did not run into
+// the other blocks.
+func (l *sheetLayout) end() error {
+	current := l.current
+	l.current = nil
+	if current == nil {
+		return nil
+	}
+	if err := l.checkOverlap(current.name, current.extent); err != nil {
+		return err
+	}
+	l.placed = append(l.placed, *current)
+	return nil
+}
+
+func (l *sheetLayout) checkOverlap(name string, r config.RangeBounds) error {
+	if l.allowOverlap {
+		return nil
+	}
+	for _, p := range l.placed {
+		if p.extent.Overlaps(r) {
+			return fmt.Errorf("block '%s' at %s overlaps block '%s' filled at %s (set allowOverlap on the sheet to permit this)",
+				name, r, p.name, p.extent)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,252 @@
+package core
+
+import (
+	"fibr-gen/config"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func newLayoutTestGenerator(data map[string][]map[string]interface{}) *Generator {
+	views := map[string]*config.DataViewConfig{
+		"v_a": {Name: "v_a", Labels: []config.LabelConfig{{Name: "a", Column: "A"}}},
+		"v_b": {Name: "v_b", Labels: []config.LabelConfig{{Name: "b", Column: "B"}}},
+	}
+	ctx := NewGenerationContext(&config.WorkbookConfig{}, config.NewMemoryConfigRegistry(views, nil), &MockFetcher{Data: data}, nil)
+	return NewGenerator(ctx)
+}
+
+func layoutTestData() map[string][]map[string]interface{} {
+	return map[string][]map[string]interface{}{
+		"v_a": {{"A": "a1"}, {"A": "a2"}, {"A": "a3"}},
+		"v_b": {{"B": "b1"}, {"B": "b2"}},
+	}
+}
+
+func checkCells(t *testing.T, f *excelize.File, sheet string, want map[string]string) {
+	t.Helper()
+	for cell, value := range want {
+		if got, _ := f.GetCellValue(sheet, cell); got != value {
+			t.Errorf("%s = %q, want %q", cell, got, value)
+		}
+	}
+}
+
+func TestSheetLayout_RelocatesBlocksBelow(t *testing.T) {
+	f := excelize.NewFile()
+	sheet := "Sheet1"
+	f.SetCellValue(sheet, "A1", "First")
+	f.SetCellValue(sheet, "A2", "{a}")
+	f.SetCellValue(sheet, "A3", "Second")
+	f.SetCellValue(sheet, "A4", "{b}")
+	f.SetCellValue(sheet, "A5", "End")
+	f.SetSheetDimension(sheet, "A1:A5")
+
+	sheetConf := &config.SheetConfig{
+		Name: sheet,
+		Blocks: []config.BlockConfig{
+			{Name: "ListA", Type: config.BlockTypeValue, Range: config.CellRange{Ref: "A2:A2"}, DataViewName: "v_a"},
+			{Name: "ListB", Type: config.BlockTypeValue, Range: config.CellRange{Ref: "A4:A4"}, DataViewName: "v_b"},
+		},
+	}
+	gen := newLayoutTestGenerator(layoutTestData())
+	if err := gen.processSheet(&ExcelizeFile{file: f}, sheetConf); err != nil {
+		t.Fatalf("processSheet failed: %v", err)
+	}
+	checkCells(t, f, sheet, map[string]string{
+		"A2": "a1", "A3": "a2", "A4": "a3",
+		"A5": "Second",
+		"A6": "b1", "A7": "b2",
+		"A8": "End",
+	})
+}
+
+func TestSheetLayout_SideBySideBlocksShareRows(t *testing.T) {
+	f := excelize.NewFile()
+	sheet := "Sheet1"
+	f.SetCellValue(sheet, "A1", "{a}")
+	f.SetCellValue(sheet, "C1", "{b}")
+	f.SetCellValue(sheet, "A2", "End")
+	f.SetSheetDimension(sheet, "A1:C2")
+
+	sheetConf := &config.SheetConfig{
+		Name: sheet,
+		Blocks: []config.BlockConfig{
+			// The shorter block first: the taller one inserts only the extra row
+			{Name: "ListB", Type: config.BlockTypeValue, Range: config.CellRange{Ref: "C1:C1"}, DataViewName: "v_b"},
+			{Name: "ListA", Type: config.BlockTypeValue, Range: config.CellRange{Ref: "A1:A1"}, DataViewName: "v_a"},
+		},
+	}
+	gen := newLayoutTestGenerator(layoutTestData())
+	if err := gen.processSheet(&ExcelizeFile{file: f}, sheetConf); err != nil {
+		t.Fatalf("processSheet failed: %v", err)
+	}
+	checkCells(t, f, sheet, map[string]string{
+		"A1": "a1", "A2": "a2", "A3": "a3",
+		"C1": "b1", "C2": "b2", "C3": "",
+		"A4": "End",
+	})
+}
+
+func TestSheetLayout_TallerTemplateAlongsideIsNotSplit(t *testing.T) {
+	f := excelize.NewFile()
+	sheet := "Sheet1"
+	f.SetCellValue(sheet, "A1", "{a}")
+	f.SetCellValue(sheet, "C1", "{b}")
+	f.SetCellValue(sheet, "C2", "sub {b}")
+	f.SetSheetDimension(sheet, "A1:C2")
+
+	sheetConf := &config.SheetConfig{
+		Name: sheet,
+		Blocks: []config.BlockConfig{
+			// Rows are inserted below the two-row template, not through it
+			{Name: "ListA", Type: config.BlockTypeValue, Range: config.CellRange{Ref: "A1:A1"}, DataViewName: "v_a"},
+			{Name: "ListB", Type: config.BlockTypeValue, Range: config.CellRange{Ref: "C1:C2"}, DataViewName: "v_b"},
+		},
+	}
+	gen := newLayoutTestGenerator(layoutTestData())
+	if err := gen.processSheet(&ExcelizeFile{file: f}, sheetConf); err != nil {
+		t.Fatalf("processSheet failed: %v", err)
+	}
+	checkCells(t, f, sheet, map[string]string{
+		"A1": "a1", "A2": "a2", "A3": "a3", "A4": "",
+		"C1": "b1", "C2": "sub b1", "C3": "b2", "C4": "sub b2", "C5": "",
+	})
+}
+
+func TestSheetLayout_TallerTemplateAlongsideProtectsTemplateCells(t *testing.T) {
+	f := excelize.NewFile()
+	sheet := "Sheet1"
+	f.SetCellValue(sheet, "A1", "{a}")
+	f.SetCellValue(sheet, "A2", "Total")
+	f.SetCellValue(sheet, "C1", "{b}")
+	f.SetCellValue(sheet, "C2", "sub {b}")
+	f.SetCellValue(sheet, "C3", "sub sub {b}")
+	f.SetSheetDimension(sheet, "A1:C3")
+
+	sheetConf := &config.SheetConfig{
+		Name: sheet,
+		Blocks: []config.BlockConfig{
+			{Name: "ListA", Type: config.BlockTypeValue, Range: config.CellRange{Ref: "A1:A1"}, DataViewName: "v_a"},
+			{Name: "ListB", Type: config.BlockTypeValue, Range: config.CellRange{Ref: "C1:C3"}, DataViewName: "v_b"},
+		},
+	}
+	gen := newLayoutTestGenerator(layoutTestData())
+	err := gen.processSheet(&ExcelizeFile{file: f}, sheetConf)
+	if err == nil || !strings.Contains(err.Error(), "ListA") || !strings.Contains(err.Error(), "A2") {
+		t.Fatalf("expected an overwrite error for ListA at A2, got %v", err)
+	}
+}
+
+func TestSheetLayout_VerticalArrangement(t *testing.T) {
+	f := excelize.NewFile()
+	sheet := "Sheet1"
+	f.SetCellValue(sheet, "A1", "{a}")
+	f.SetCellValue(sheet, "A2", "{b}")
+	f.SetCellValue(sheet, "B2", "Note")
+	f.SetSheetDimension(sheet, "A1:B2")
+
+	sheetConf := &config.SheetConfig{
+		Name:                sheet,
+		VerticalArrangement: true,
+		Blocks: []config.BlockConfig{
+			{Name: "Across", Type: config.BlockTypeValue, Direction: config.DirectionHorizontal, Range: config.CellRange{Ref: "A1:A1"}, DataViewName: "v_a"},
+			{Name: "Down", Type: config.BlockTypeValue, Range: config.CellRange{Ref: "A2:B2"}, DataViewName: "v_b"},
+		},
+	}
+	gen := newLayoutTestGenerator(layoutTestData())
+	if err := gen.processSheet(&ExcelizeFile{file: f}, sheetConf); err != nil {
+		t.Fatalf("processSheet failed: %v", err)
+	}
+	// No columns are inserted through the block below
+	checkCells(t, f, sheet, map[string]string{
+		"A1": "a1", "B1": "a2", "C1": "a3",
+		"A2": "b1", "B2": "Note", "A3": "b2", "B3": "Note",
+	})
+}
+
+func TestSheetLayout_Overlap(t *testing.T) {
+	sheetConf := config.SheetConfig{
+		Name: "Sheet1",
+		Blocks: []config.BlockConfig{
+			{Name: "ListA", Type: config.BlockTypeValue, Range: config.CellRange{Ref: "A1:B1"}, DataViewName: "v_a"},
+			{Name: "ListB", Type: config.BlockTypeValue, Range: config.CellRange{Ref: "B1:C1"}, DataViewName: "v_b"},
+		},
+	}
+	for _, allow := range []bool{false, true} {
+		f := excelize.NewFile()
+		f.SetCellValue("Sheet1", "A1", "{a}")
+		f.SetCellValue("Sheet1", "C1", "{b}")
+		conf := sheetConf
+		conf.AllowOverlap = allow
+
+		err := newLayoutTestGenerator(layoutTestData()).processSheet(&ExcelizeFile{file: f}, &conf)
+		if allow && err != nil {
+			t.Errorf("AllowOverlap: unexpected error %v", err)
+		}
+		if !allow && (err == nil || !strings.Contains(err.Error(), "block 'ListB' at B1:C1 overlaps block 'ListA' filled at A1:B3")) {
+			t.Errorf("error = %v, want overlap of ListB with ListA", err)
+		}
+	}
+}
+
+func TestSheetLayout_VerticalArrangementProtectsTemplateCells(t *testing.T) {
+	for _, allow := range []bool{false, true} {
+		f := excelize.NewFile()
+		sheet := "Sheet1"
+		f.SetCellValue(sheet, "A1", "{a}")
+		f.SetCellValue(sheet, "C1", "Remark") // Template text right of the block
+		f.SetSheetDimension(sheet, "A1:C1")
+		sheetConf := &config.SheetConfig{
+			Name:                sheet,
+			VerticalArrangement: true,
+			AllowOverlap:        allow,
+			Blocks: []config.BlockConfig{
+				{Name: "Across", Type: config.BlockTypeValue, Direction: config.DirectionHorizontal, Range: config.CellRange{Ref: "A1:A1"}, DataViewName: "v_a"},
+			},
+		}
+
+		err := newLayoutTestGenerator(layoutTestData()).processSheet(&ExcelizeFile{file: f}, sheetConf)
+		if allow {
+			if err != nil {
+				t.Fatalf("AllowOverlap: unexpected error %v", err)
+			}
+			checkCells(t, f, sheet, map[string]string{"A1": "a1", "B1": "a2", "C1": "a3"})
+		} else if err == nil || !strings.Contains(err.Error(), "block 'Across' expanding over B1:C1 overwrites template cell C1") {
+			t.Errorf("error = %v, want overlap with the template cell C1", err)
+		}
+	}
+}
+
+func TestSheetLayout_Relocate(t *testing.T) {
+	l := &sheetLayout{}
+	l.record(true, 3, 2)  // 2 rows before row 3
+	l.record(false, 2, 1) // 1 column before column B
+	block := &config.BlockConfig{
+		Range:     config.CellRange{Ref: "A2:C4"},
+		SubBlocks: []config.BlockConfig{{Range: config.CellRange{Ref: "B4:B4"}}, {Range: config.CellRange{Ref: "A1:A1"}}},
+	}
+	got := l.relocate(block)
+	if got.Range.Ref != "A2:D6" {
+		t.Errorf("range = %s, want A2:D6", got.Range.Ref)
+	}
+	if got.SubBlocks[0].Range.Ref != "C6:C6" || got.SubBlocks[1].Range.Ref != "A1:A1" {
+		t.Errorf("sub-block ranges = %s, %s, want C6:C6, A1:A1", got.SubBlocks[0].Range.Ref, got.SubBlocks[1].Range.Ref)
+	}
+	if block.Range.Ref != "A2:C4" || block.SubBlocks[0].Range.Ref != "B4:B4" {
+		t.Error("relocate modified the configured block")
+	}
+
+	// Rows inserted at the same place are shared, columns too
+	if at, n, _ := l.reserve(true, 3, 3, nil); at != 5 || n != 1 {
+		t.Errorf("reserve rows = %d, %d, want 5, 1", at, n)
+	}
+	if at, n, _ := l.reserve(true, 7, 3, nil); at != 7 || n != 3 {
+		t.Errorf("reserve other rows = %d, %d, want 7, 3", at, n)
+	}
+	l.vertical = true
+	if _, n, _ := l.reserve(false, 5, 2, nil); n != 0 {
+		t.Errorf("reserve columns in vertical arrangement = %d, want 0", n)
+	}
+}
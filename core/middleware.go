@@ -0,0 +1,163 @@
+package core
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/aws/smithy-go"
+)
+
+// FetcherMiddleware decorates a fetcher with cross-cutting behaviour
+// (retries, timeouts, logging, metrics).
+type FetcherMiddleware func(next ContextDataFetcher) ContextDataFetcher
+
+// Chain wraps fetcher with middlewares; the first middleware is the outermost.
+func Chain(fetcher DataFetcher, middlewares ...FetcherMiddleware) ContextDataFetcher {
+	wrapped := WithContext(fetcher)
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		wrapped = middlewares[i](wrapped)
+	}
+	return wrapped
+}
+
+// FetchFunc adapts a function to ContextDataFetcher.
+type FetchFunc func(ctx context.Context, viewName string, params map[string]string) ([]map[string]interface{}, error)
+
+func (fn FetchFunc) Fetch(viewName string, params map[string]string) ([]map[string]interface{}, error) {
+	return fn(context.Background(), viewName, params)
+}
+
+func (fn FetchFunc) FetchContext(ctx context.Context, viewName string, params map[string]string) ([]map[string]interface{}, error) {
+	return fn(ctx, viewName, params)
+}
+
+// RetryOptions configures Retry.
+type RetryOptions struct {
+	MaxRetries     int              // retries after the first attempt
+	InitialBackoff time.Duration    // delay before the first retry, doubled per retry (default 200ms)
+	MaxBackoff     time.Duration    // cap on the delay (default 10s)
+	Retryable      func(error) bool // classifies errors (default DefaultRetryable)
+}
+
+// Retry retries fetches failing with retryable errors, with exponential backoff.
+// It stops early when the caller's context is done.
+func Retry(opts RetryOptions) FetcherMiddleware {
+	if opts.InitialBackoff <= 0 {
+		opts.InitialBackoff = 200 * time.Millisecond
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 10 * time.Second
+	}
+	if opts.Retryable == nil {
+		opts.Retryable = DefaultRetryable
+	}
+	return func(next ContextDataFetcher) ContextDataFetcher {
+		return FetchFunc(func(ctx context.Context, viewName string, params map[string]string) ([]map[string]interface{}, error) {
+			backoff := opts.InitialBackoff
+			for attempt := 0; ; attempt++ {
+				rows, err := next.FetchContext(ctx, viewName, params)
+				if err == nil || attempt >= opts.MaxRetries || ctx.Err() != nil || !opts.Retryable(err) {
+					return rows, err
+				}
+				slog.Warn("Retrying fetch", "view", viewName, "attempt", attempt+1, "backoff", backoff, "error", err)
+				select {
+				case <-time.After(backoff):
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+				backoff = min(backoff*2, opts.MaxBackoff)
+			}
+		})
+	}
+}
+
+// DefaultRetryable reports whether err looks transient: timeouts, dropped
+// connections, HTTP 429 / 5xx responses and AWS throttling or server errors.
+// Cancellation is never retried.
+func DefaultRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return isRetryableHttpError(statusErr)
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "ProvisionedThroughputExceededException", "ThrottlingException", "RequestLimitExceeded",
+			"InternalServerError", "ServiceUnavailable", "SlowDown":
+			return true
+		}
+	}
+	return false
+}
+
+// Timeout bounds each fetch (each attempt, when wrapped by Retry) to d.
+func Timeout(d time.Duration) FetcherMiddleware {
+	return func(next ContextDataFetcher) ContextDataFetcher {
+		return FetchFunc(func(ctx context.Context, viewName string, params map[string]string) ([]map[string]interface{}, error) {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+			return next.FetchContext(ctx, viewName, params)
+		})
+	}
+}
+
+// Logging logs every fetch with its view, params, row count and duration.
+// A nil logger uses slog.Default().
+func Logging(logger *slog.Logger) FetcherMiddleware {
+	return func(next ContextDataFetcher) ContextDataFetcher {
+		return FetchFunc(func(ctx context.Context, viewName string, params map[string]string) ([]map[string]interface{}, error) {
+			l := logger
+			if l == nil {
+				l = slog.Default()
+			}
+			start := time.Now()
+			rows, err := next.FetchContext(ctx, viewName, params)
+			if err != nil {
+				l.ErrorContext(ctx, "Fetch failed", "view", viewName, "params", params, "duration", time.Since(start), "error", err)
+			} else {
+				l.InfoContext(ctx, "Fetched", "view", viewName, "params", params, "rows", len(rows), "duration", time.Since(start))
+			}
+			return rows, err
+		})
+	}
+}
+
+// FetchMetrics describes one completed fetch.
+type FetchMetrics struct {
+	View     string
+	Rows     int
+	Duration time.Duration
+	Err      error
+}
+
+// Metrics reports every fetch to hook, e.g. to feed Prometheus or CloudWatch.
+func Metrics(hook func(FetchMetrics)) FetcherMiddleware {
+	return func(next ContextDataFetcher) ContextDataFetcher {
+		return FetchFunc(func(ctx context.Context, viewName string, params map[string]string) ([]map[string]interface{}, error) {
+			start := time.Now()
+			rows, err := next.FetchContext(ctx, viewName, params)
+			hook(FetchMetrics{View: viewName, Rows: len(rows), Duration: time.Since(start), Err: err})
+			return rows, err
+		})
+	}
+}
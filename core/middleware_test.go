@@ -0,0 +1,138 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fibr-gen/config"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// flakyFetcher fails with err for its first failures calls.
+type flakyFetcher struct {
+	failures int
+	err      error
+	calls    int
+}
+
+func (f *flakyFetcher) Fetch(viewName string, params map[string]string) ([]map[string]interface{}, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, f.err
+	}
+	return []map[string]interface{}{{"ID": "1"}}, nil
+}
+
+func TestRetry(t *testing.T) {
+	transient := fmt.Errorf("query: %w", context.DeadlineExceeded)
+	permanent := errors.New("syntax error")
+
+	tests := []struct {
+		name      string
+		fetcher   *flakyFetcher
+		retries   int
+		wantErr   bool
+		wantCalls int
+	}{
+		{"Recovers from transient errors", &flakyFetcher{failures: 2, err: transient}, 3, false, 3},
+		{"Gives up after max retries", &flakyFetcher{failures: 5, err: transient}, 2, true, 3},
+		{"Does not retry permanent errors", &flakyFetcher{failures: 1, err: permanent}, 3, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := Chain(tt.fetcher, Retry(RetryOptions{MaxRetries: tt.retries, InitialBackoff: time.Millisecond}))
+			_, err := fetcher.FetchContext(context.Background(), "view1", nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.fetcher.calls != tt.wantCalls {
+				t.Fatalf("calls = %d, want %d", tt.fetcher.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetry_CustomClassifierAndCancellation(t *testing.T) {
+	inner := &flakyFetcher{failures: 10, err: errors.New("deadlock detected")}
+	ctx, cancel := context.WithCancel(context.Background())
+	fetcher := Chain(inner, Retry(RetryOptions{
+		MaxRetries:     10,
+		InitialBackoff: time.Millisecond,
+		Retryable: func(err error) bool {
+			if inner.calls == 2 {
+				cancel()
+			}
+			return strings.Contains(err.Error(), "deadlock")
+		},
+	}))
+
+	if _, err := fetcher.FetchContext(ctx, "view1", nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want context.Canceled", err)
+	}
+	if inner.calls != 2 {
+		t.Fatalf("calls = %d, want 2", inner.calls)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	slow := FetchFunc(func(ctx context.Context, viewName string, params map[string]string) ([]map[string]interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	_, err := Chain(slow, Timeout(5*time.Millisecond)).FetchContext(context.Background(), "view1", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestLoggingAndMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	var got []FetchMetrics
+
+	fetcher := Chain(&flakyFetcher{}, Metrics(func(m FetchMetrics) { got = append(got, m) }), Logging(logger))
+	if _, err := fetcher.Fetch("view1", map[string]string{"month": "2025-01"}); err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+
+	if len(got) != 1 || got[0].View != "view1" || got[0].Rows != 1 || got[0].Err != nil {
+		t.Fatalf("metrics = %+v, want one successful view1 fetch of 1 row", got)
+	}
+	logged := buf.String()
+	for _, want := range []string{"view=view1", "month:2025-01", "rows=1", "duration="} {
+		if !strings.Contains(logged, want) {
+			t.Errorf("log %q does not contain %q", logged, want)
+		}
+	}
+}
+
+func TestRoutingDataFetcher_AppliesFetchPolicy(t *testing.T) {
+	views := map[string]*config.DataViewConfig{
+		"v1": {Name: "v1", DataSource: "ds1"},
+	}
+	sources := map[string]*config.DataSourceConfig{
+		"ds1": {Name: "ds1", Driver: "mock", DSN: "unused", Fetch: &config.FetchPolicy{Retries: 2, RetryBackoff: "1ms"}},
+	}
+	router := NewRoutingDataFetcher(config.NewMemoryConfigRegistry(views, sources))
+	inner := &flakyFetcher{failures: 1, err: &httpStatusError{StatusCode: 503}}
+	router.Register("mock", func(_ context.Context, source *config.DataSourceConfig) (DataFetcher, error) {
+		return inner, nil
+	})
+	var metrics []FetchMetrics
+	router.Metrics = func(m FetchMetrics) { metrics = append(metrics, m) }
+
+	rows, err := router.Fetch("v1", nil)
+	if err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	if len(rows) != 1 || inner.calls != 2 {
+		t.Fatalf("rows = %v after %d calls, want 1 row after 2 calls", rows, inner.calls)
+	}
+	if len(metrics) != 1 {
+		t.Fatalf("metrics = %+v, want one entry per view fetch", metrics)
+	}
+}
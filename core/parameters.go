@@ -0,0 +1,86 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// substituteSheetParams replaces ${param} references in the text cells and
+// the page headers / footers of a sheet. Formula cells are left alone, as are
+// references to unknown parameters.
+func (g *Generator) substituteSheetParams(f ExcelFile, sheet string, params map[string]string) error {
+	maxC, maxR, err := sheetBounds(f, sheet)
+	if err != nil {
+		return err
+	}
+	for r := 1; r <= maxR; r++ {
+		for c := 1; c <= maxC; c++ {
+			cn, _ := excelize.CoordinatesToCellName(c, r)
+			val, err := f.GetCellValue(sheet, cn)
+			if err != nil || !strings.Contains(val, "${") {
+				continue
+			}
+			if formula, _ := f.GetCellFormula(sheet, cn); formula != "" {
+				continue
+			}
+			if replaced := replacePlaceholders(val, params); replaced != val {
+				if err := f.SetCellValue(sheet, cn, replaced); err != nil {
+					return fmt.Errorf("failed to substitute parameters in %s: %w", cn, err)
+				}
+			}
+		}
+	}
+
+	hf, err := f.GetHeaderFooter(sheet)
+	if err != nil || hf == nil {
+		return err
+	}
+	changed := false
+	for _, text := range []*string{&hf.OddHeader, &hf.OddFooter, &hf.EvenHeader, &hf.EvenFooter, &hf.FirstHeader, &hf.FirstFooter} {
+		if replaced := replacePlaceholders(*text, params); replaced != *text {
+			*text = replaced
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	if err := f.SetHeaderFooter(sheet, hf); err != nil {
+		return fmt.Errorf("failed to substitute parameters in header/footer: %w", err)
+	}
+	return nil
+}
+
+// substituteWorkbookParams replaces ${param} references in sheet names and
+// document properties. Sheet names are substituted once all sheets are
+// processed, since the sheet configs refer to the template's names.
+func (g *Generator) substituteWorkbookParams(f ExcelFile, params map[string]string) error {
+	for _, sheet := range f.GetSheetList() {
+		if name := replacePlaceholders(sheet, params); name != sheet {
+			if err := f.SetSheetName(sheet, name); err != nil {
+				return fmt.Errorf("failed to rename sheet %s to %s: %w", sheet, name, err)
+			}
+		}
+	}
+
+	props, err := f.GetDocProps()
+	if err != nil {
+		return fmt.Errorf("failed to read document properties: %w", err)
+	}
+	changed := false
+	for _, text := range []*string{&props.Title, &props.Subject, &props.Description, &props.Keywords, &props.Category, &props.Creator, &props.ContentStatus} {
+		if replaced := replacePlaceholders(*text, params); replaced != *text {
+			*text = replaced
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	if err := f.SetDocProps(props); err != nil {
+		return fmt.Errorf("failed to write document properties: %w", err)
+	}
+	return nil
+}
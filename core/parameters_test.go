@@ -0,0 +1,93 @@
+package core
+
+import (
+	"fibr-gen/config"
+	"path/filepath"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestGenerate_SubstitutesParameters(t *testing.T) {
+	dir := t.TempDir()
+	f := excelize.NewFile()
+	f.SetCellValue("Sheet1", "A1", "Sales report for ${month}")
+	f.SetCellValue("Sheet1", "B1", "${unknown} stays")
+	f.SetCellValue("Sheet1", "A3", "{name}")
+	f.SetHeaderFooter("Sheet1", &excelize.HeaderFooterOptions{OddHeader: "&CReport ${month}", OddFooter: "&RPage &P"})
+	f.NewSheet("Summary ${year}")
+	f.SetCellValue("Summary ${year}", "A1", "Year ${year}")
+	f.NewSheet("Region")
+	f.SetCellValue("Region", "A1", "${region} in ${month}")
+	f.SetDocProps(&excelize.DocProperties{Title: "Sales ${month}", Creator: "fibr"})
+	if err := f.SaveAs(filepath.Join(dir, "template.xlsx")); err != nil {
+		t.Fatalf("save template: %v", err)
+	}
+
+	wbConfig := &config.WorkbookConfig{
+		Name:       "Sales_${month}",
+		Template:   "template.xlsx",
+		OutputDir:  "out",
+		Parameters: map[string]string{"month": "2025-03", "year": "2025"},
+		Sheets: []config.SheetConfig{
+			{
+				Name: "Sheet1",
+				Blocks: []config.BlockConfig{{
+					Name:         "Names",
+					Type:         config.BlockTypeValue,
+					Range:        config.CellRange{Ref: "A3:A3"},
+					DataViewName: "v_names",
+				}},
+			},
+			{Name: "Region", Dynamic: true, DataViewName: "v_regions", ParamLabel: "region"},
+		},
+	}
+	views := map[string]*config.DataViewConfig{
+		"v_names":   {Name: "v_names", Labels: []config.LabelConfig{{Name: "name", Column: "NAME"}}},
+		"v_regions": {Name: "v_regions", Labels: []config.LabelConfig{{Name: "region", Column: "REGION"}}},
+	}
+	fetcher := &MockFetcher{Data: map[string][]map[string]interface{}{
+		"v_names":   {{"NAME": "${month} is data"}},
+		"v_regions": {{"REGION": "East"}, {"REGION": "West"}},
+	}}
+	gen := NewGenerator(NewGenerationContext(wbConfig, config.NewMemoryConfigRegistry(views, nil), fetcher, nil))
+	if err := gen.Generate(dir, dir); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	out, err := excelize.OpenFile(filepath.Join(dir, "out", "Sales_2025-03.xlsx"))
+	if err != nil {
+		t.Fatalf("open output: %v", err)
+	}
+	defer out.Close()
+
+	cells := []struct{ sheet, cell, want string }{
+		{"Sheet1", "A1", "Sales report for 2025-03"},
+		{"Sheet1", "B1", "${unknown} stays"},
+		{"Sheet1", "A3", "${month} is data"},
+		{"Summary 2025", "A1", "Year 2025"},
+		{"East", "A1", "East in 2025-03"},
+		{"West", "A1", "West in 2025-03"},
+	}
+	for _, c := range cells {
+		if got, err := out.GetCellValue(c.sheet, c.cell); err != nil || got != c.want {
+			t.Errorf("%s!%s = %q (%v), want %q", c.sheet, c.cell, got, err, c.want)
+		}
+	}
+
+	hf, err := out.GetHeaderFooter("Sheet1")
+	if err != nil || hf == nil {
+		t.Fatalf("GetHeaderFooter: %v, %v", hf, err)
+	}
+	if hf.OddHeader != "&CReport 2025-03" || hf.OddFooter != "&RPage &P" {
+		t.Errorf("header/footer = %q / %q", hf.OddHeader, hf.OddFooter)
+	}
+
+	props, err := out.GetDocProps()
+	if err != nil {
+		t.Fatalf("GetDocProps: %v", err)
+	}
+	if props.Title != "Sales 2025-03" || props.Creator != "fibr" {
+		t.Errorf("doc props title = %q, creator = %q", props.Title, props.Creator)
+	}
+}
@@ -0,0 +1,294 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fixture is the recorded result of one Fetch, stored as
+// <Dir>/<view>/<params key>.json by RecordingFetcher.
+type Fixture struct {
+	View   string                   `json:"view"`
+	Params map[string]string        `json:"params"`
+	Rows   []map[string]interface{} `json:"rows"`
+}
+
+// RecordingFetcher wraps a DataFetcher and saves every successful fetch as a
+// fixture in Dir, so that a run can be reproduced offline with ReplayFetcher.
+// The run's resolved parameters are saved with RecordParams, as fixtures are
+// keyed by parameters that may depend on the day of the run (archive_date,
+// $date: values).
+type RecordingFetcher struct {
+	Fetcher DataFetcher
+	Dir     string
+}
+
+func NewRecordingFetcher(fetcher DataFetcher, dir string) *RecordingFetcher {
+	return &RecordingFetcher{Fetcher: fetcher, Dir: dir}
+}
+
+func (r *RecordingFetcher) Fetch(viewName string, params map[string]string) ([]map[string]interface{}, error) {
+	return r.FetchContext(context.Background(), viewName, params)
+}
+
+func (r *RecordingFetcher) FetchContext(ctx context.Context, viewName string, params map[string]string) ([]map[string]interface{}, error) {
+	rows, err := WithContext(r.Fetcher).FetchContext(ctx, viewName, params)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFixture(r.Dir, Fixture{View: viewName, Params: params, Rows: rows}); err != nil {
+		return nil, fmt.Errorf("failed to record view '%s': %w", viewName, err)
+	}
+	return rows, nil
+}
+
+// RecordParams saves the resolved parameters of the recorded run, to be
+// restored on replay through ReplayFetcher.Params.
+func (r *RecordingFetcher) RecordParams(params map[string]string) error {
+	data, err := json.MarshalIndent(params, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(r.Dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(r.Dir, runParamsFile), data, 0644)
+}
+
+// runParamsFile holds the parameters saved by RecordParams. Its name cannot
+// clash with the fixture directories, which never contain '@'.
+const runParamsFile = "@params.json"
+
+// ReplayFetcher serves the fixtures recorded by RecordingFetcher. A fetch
+// whose view and params were not recorded fails.
+type ReplayFetcher struct {
+	Dir string
+}
+
+func NewReplayFetcher(dir string) *ReplayFetcher {
+	return &ReplayFetcher{Dir: dir}
+}
+
+func (r *ReplayFetcher) Fetch(viewName string, params map[string]string) ([]map[string]interface{}, error) {
+	path := fixturePath(r.Dir, viewName, params)
+	fixture, err := readFixture(path)
+	if err != nil {
+		return nil, fmt.Errorf("no fixture for view '%s' with params %v: %w", viewName, params, err)
+	}
+	return fixture.Rows, nil
+}
+
+// Params returns the parameters of the recorded run, or nil if they were not
+// recorded. Using them for the replayed run resolves dynamic dates as they
+// were when recording, so that fetches find their fixtures on any later day.
+func (r *ReplayFetcher) Params() (map[string]string, error) {
+	data, err := os.ReadFile(filepath.Join(r.Dir, runParamsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var params map[string]string
+	if err := json.Unmarshal(data, &params); err != nil {
+		return nil, fmt.Errorf("failed to decode recorded params: %w", err)
+	}
+	return params, nil
+}
+
+// fixturePath returns <dir>/<view>/<key>.json, where key hashes the view and params.
+func fixturePath(dir, viewName string, params map[string]string) string {
+	sum := sha256.Sum256([]byte(fetchKey(viewName, params)))
+	return filepath.Join(dir, fixtureDirName(viewName), hex.EncodeToString(sum[:8])+".json")
+}
+
+// fixtureDirName makes a view name safe to use as a directory name.
+func fixtureDirName(viewName string) string {
+	name := strings.Map(func(c rune) rune {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+			return c
+		}
+		return '_'
+	}, viewName)
+	if strings.Trim(name, ".") == "" {
+		name = "_" + name
+	}
+	return name
+}
+
+func writeFixture(dir string, fixture Fixture) error {
+	return writeFixtureFile(fixturePath(dir, fixture.View, fixture.Params), fixture)
+}
+
+func writeFixtureFile(path string, fixture Fixture) error {
+	rows := make([]map[string]interface{}, len(fixture.Rows))
+	for i, row := range fixture.Rows {
+		tagged := make(map[string]interface{}, len(row))
+		for k, v := range row {
+			tagged[k] = tagFixtureValue(v)
+		}
+		rows[i] = tagged
+	}
+	data, err := json.MarshalIndent(Fixture{View: fixture.View, Params: fixture.Params, Rows: rows}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	// Write then rename, so a concurrent replay never reads a partial fixture.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".fixture-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func readFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var fixture Fixture
+	if err := dec.Decode(&fixture); err != nil {
+		return nil, fmt.Errorf("failed to decode fixture %s: %w", path, err)
+	}
+	for _, row := range fixture.Rows {
+		for k, v := range row {
+			val, err := untagFixtureValue(v)
+			if err != nil {
+				return nil, fmt.Errorf("fixture %s column '%s': %w", path, k, err)
+			}
+			row[k] = val
+		}
+	}
+	return &fixture, nil
+}
+
+// Values JSON cannot round-trip are tagged, e.g. {"$type": "int", "value": 42},
+// at any depth of nested maps and lists. Strings, booleans, nulls and float64
+// values are stored as plain JSON values; maps that have a "$type" key of
+// their own are wrapped as {"$type": "map", "value": {...}}.
+const fixtureTypeKey = "$type"
+
+type taggedValue struct {
+	Type  string      `json:"$type"`
+	Value interface{} `json:"value"`
+}
+
+func tagFixtureValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case nil, string, bool, float64:
+		return val
+	case float32:
+		return taggedValue{Type: "float32", Value: val}
+	case time.Time:
+		return taggedValue{Type: "time", Value: val.Format(time.RFC3339Nano)}
+	case []byte:
+		return taggedValue{Type: "bytes", Value: base64.StdEncoding.EncodeToString(val)}
+	case []interface{}:
+		tagged := make([]interface{}, len(val))
+		for i, item := range val {
+			tagged[i] = tagFixtureValue(item)
+		}
+		return tagged
+	case map[string]interface{}:
+		tagged := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			tagged[k] = tagFixtureValue(item)
+		}
+		if _, ok := val[fixtureTypeKey]; ok {
+			return taggedValue{Type: "map", Value: tagged}
+		}
+		return tagged
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return taggedValue{Type: "int", Value: rv.Int()}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return taggedValue{Type: "uint", Value: rv.Uint()}
+	}
+	return v
+}
+
+func untagFixtureValue(v interface{}) (interface{}, error) {
+	switch val := v.(type) {
+	case json.Number:
+		return val.Float64()
+	case []interface{}:
+		for i, item := range val {
+			untagged, err := untagFixtureValue(item)
+			if err != nil {
+				return nil, err
+			}
+			val[i] = untagged
+		}
+		return val, nil
+	case map[string]interface{}:
+		typ, ok := val[fixtureTypeKey].(string)
+		if !ok {
+			return untagFixtureMap(val)
+		}
+		num, _ := val["value"].(json.Number)
+		str, _ := val["value"].(string)
+		switch typ {
+		case "int":
+			return num.Int64()
+		case "uint":
+			return strconv.ParseUint(num.String(), 10, 64)
+		case "float32":
+			f, err := strconv.ParseFloat(num.String(), 32)
+			return float32(f), err
+		case "time":
+			return time.Parse(time.RFC3339Nano, str)
+		case "bytes":
+			return base64.StdEncoding.DecodeString(str)
+		case "map":
+			m, ok := val["value"].(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("tagged map holds %T", val["value"])
+			}
+			return untagFixtureMap(m)
+		default:
+			return nil, fmt.Errorf("unknown value type '%s'", typ)
+		}
+	}
+	return v, nil
+}
+
+// untagFixtureMap untags the values of a map in place.
+func untagFixtureMap(m map[string]interface{}) (map[string]interface{}, error) {
+	for k, item := range m {
+		untagged, err := untagFixtureValue(item)
+		if err != nil {
+			return nil, fmt.Errorf("key '%s': %w", k, err)
+		}
+		m[k] = untagged
+	}
+	return m, nil
+}
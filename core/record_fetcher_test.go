@@ -0,0 +1,150 @@
+package core
+
+import (
+	"errors"
+	"fibr-gen/config"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	rows := []map[string]interface{}{
+		{
+			"ID":      int64(42),
+			"AMOUNT":  12.5,
+			"NAME":    "Alice",
+			"ACTIVE":  true,
+			"NOTE":    nil,
+			"CREATED": time.Date(2025, 1, 15, 9, 30, 0, 0, time.UTC),
+			"BLOB":    []byte{0x00, 0xff},
+			"TAGS":    []interface{}{"a", "b"},
+		},
+	}
+	mock := &MockDataFetcher{Data: map[string][]map[string]interface{}{"orders/v1": rows}}
+	params := map[string]string{"month": "2025-01", "region": "EU"}
+
+	recorder := NewRecordingFetcher(mock, dir)
+	if _, err := recorder.Fetch("orders/v1", params); err != nil {
+		t.Fatalf("record error: %v", err)
+	}
+
+	replay := NewReplayFetcher(dir)
+	got, err := replay.Fetch("orders/v1", map[string]string{"region": "EU", "month": "2025-01"})
+	if err != nil {
+		t.Fatalf("replay error: %v", err)
+	}
+	if !reflect.DeepEqual(got, rows) {
+		t.Fatalf("replayed rows = %#v, want %#v", got, rows)
+	}
+
+	_, err = replay.Fetch("orders/v1", map[string]string{"month": "2025-02", "region": "EU"})
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("replay of unrecorded params error = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestRecordAndReplay_NestedValues(t *testing.T) {
+	dir := t.TempDir()
+	rows := []map[string]interface{}{
+		{
+			"id":    int64(7),
+			"ratio": float32(0.25),
+			"owner": map[string]interface{}{
+				"age":     int64(31),
+				"score":   9.5,
+				"joined":  time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+				"aliases": []interface{}{"al", int64(2)},
+				"raw":     map[string]interface{}{"$type": "custom", "n": uint64(3)},
+			},
+			"history": []interface{}{
+				map[string]interface{}{"year": int64(2024), "total": float32(1.5)},
+				[]interface{}{int64(1), nil, true},
+			},
+		},
+	}
+	mock := &MockDataFetcher{Data: map[string][]map[string]interface{}{"people": rows}}
+
+	if _, err := NewRecordingFetcher(mock, dir).Fetch("people", nil); err != nil {
+		t.Fatalf("record error: %v", err)
+	}
+	got, err := NewReplayFetcher(dir).Fetch("people", nil)
+	if err != nil {
+		t.Fatalf("replay error: %v", err)
+	}
+	if !reflect.DeepEqual(got, rows) {
+		t.Fatalf("replayed rows = %#v, want %#v", got, rows)
+	}
+}
+
+func TestRecordingFetcher_DoesNotRecordFailures(t *testing.T) {
+	dir := t.TempDir()
+	recorder := NewRecordingFetcher(&MockDataFetcher{}, dir)
+	if _, err := recorder.Fetch("missing", nil); err == nil {
+		t.Fatal("expected error for unknown view")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("expected no fixtures, got %d entries", len(entries))
+	}
+}
+
+func TestReplay_LaterDayUsesRecordedParams(t *testing.T) {
+	dir := t.TempDir()
+	views := map[string]*config.DataViewConfig{
+		"daily": {
+			Name:    "daily",
+			Labels:  []config.LabelConfig{{Name: "day", Column: "day"}},
+			Filters: []config.FilterConfig{{Label: "day", Value: "${archive_date}"}},
+		},
+	}
+	provider := config.NewMemoryConfigRegistry(views, nil)
+	wb := &config.WorkbookConfig{ArchiveRule: "$date:day:day:-1"}
+	mock := &MockDataFetcher{Data: map[string][]map[string]interface{}{
+		"daily": {{"day": "2025-03-09"}, {"day": "2025-03-10"}},
+	}}
+
+	defer func(orig func() time.Time) { now = orig }(now)
+	now = func() time.Time { return time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC) }
+
+	recorder := NewRecordingFetcher(mock, dir)
+	recordCtx := NewGenerationContext(wb, provider, recorder, nil)
+	if err := recorder.RecordParams(recordCtx.Parameters); err != nil {
+		t.Fatalf("RecordParams error: %v", err)
+	}
+	recorded, err := recordCtx.GetDataView("daily")
+	if err != nil {
+		t.Fatalf("record error: %v", err)
+	}
+
+	// A week later archive_date resolves to another day.
+	now = func() time.Time { return time.Date(2025, 3, 17, 8, 0, 0, 0, time.UTC) }
+	replay := NewReplayFetcher(dir)
+	if _, err := NewGenerationContext(wb, provider, replay, nil).GetDataView("daily"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("replay with today's params error = %v, want os.ErrNotExist", err)
+	}
+
+	replayCtx := NewGenerationContext(wb, provider, replay, nil)
+	params, err := replay.Params()
+	if err != nil {
+		t.Fatalf("Params error: %v", err)
+	}
+	for k, v := range params {
+		replayCtx.Parameters[k] = v
+	}
+	replayed, err := replayCtx.GetDataView("daily")
+	if err != nil {
+		t.Fatalf("replay error: %v", err)
+	}
+	if !reflect.DeepEqual(replayed.Data, recorded.Data) || len(replayed.Data) != 1 {
+		t.Fatalf("replayed rows = %v, want %v", replayed.Data, recorded.Data)
+	}
+}
+
+func TestReplayFetcher_ParamsNotRecorded(t *testing.T) {
+	params, err := NewReplayFetcher(t.TempDir()).Params()
+	if err != nil || params != nil {
+		t.Fatalf("Params = %v, %v, want nil, nil", params, err)
+	}
+}
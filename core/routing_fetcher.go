@@ -0,0 +1,304 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fibr-gen/config"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+)
+
+// FetcherFactory opens a DataFetcher for a configured data source. ctx is the
+// context of the fetch that opens the source: it bounds connecting and loading
+// credentials, not the fetcher's later use.
+type FetcherFactory func(ctx context.Context, source *config.DataSourceConfig) (DataFetcher, error)
+
+// RoutingDataFetcher implements DataFetcher by dispatching each view to the
+// fetcher of its configured DataSource. Fetchers are opened lazily, once per
+// data source, using the factory registered for the source's driver, and
+// wrapped with the middleware of the source's FetchPolicy.
+type RoutingDataFetcher struct {
+	Provider  config.Provider
+	Factories map[string]FetcherFactory
+	Metrics   func(FetchMetrics) // Optional: reports every fetch of every source
+
+	mu       sync.Mutex
+	fetchers map[string]ContextDataFetcher // data source name -> opened (wrapped) fetcher
+	closers  map[string]io.Closer          // data source name -> fetcher holding resources
+	opening  map[string]*pendingOpen       // data source name -> open in progress
+	closed   bool
+}
+
+// errFetcherClosed is returned by fetches after the fetcher is closed.
+var errFetcherClosed = errors.New("routing data fetcher is closed")
+
+// NewRoutingDataFetcher creates a fetcher with the built-in factories registered:
+// "csv", "json" and "excel" (DSN is the root directory), "http" (DSN is the base
+// URL), "mysql", "postgres" and "sqlite" (DSN is the connection string or
+// database file) and "dynamodb" (credentials from the default AWS chain).
+// SQL drivers must be registered with database/sql by the binary.
+func NewRoutingDataFetcher(provider config.Provider) *RoutingDataFetcher {
+	r := &RoutingDataFetcher{
+		Provider:  provider,
+		Factories: make(map[string]FetcherFactory),
+		fetchers:  make(map[string]ContextDataFetcher),
+		closers:   make(map[string]io.Closer),
+	}
+	r.Register("csv", r.openCsvSource)
+	r.Register("json", r.openJsonSource)
+	r.Register("excel", r.openExcelSource)
+	r.Register("http", r.openHttpSource)
+	r.Register("mysql", r.openSQLSource)
+	r.Register("postgres", r.openSQLSource)
+	r.Register("sqlite", r.openSQLSource)
+	r.Register("dynamodb", r.openDynamoDBSource)
+	return r
+}
+
+// Register adds or replaces the factory used for the given driver.
+func (r *RoutingDataFetcher) Register(driver string, factory FetcherFactory) {
+	r.Factories[driver] = factory
+}
+
+// Fetch resolves the view's DataSource and delegates to that source's fetcher.
+func (r *RoutingDataFetcher) Fetch(viewName string, params map[string]string) ([]map[string]interface{}, error) {
+	return r.FetchContext(context.Background(), viewName, params)
+}
+
+// FetchContext is Fetch, passing ctx on to the source's fetcher.
+func (r *RoutingDataFetcher) FetchContext(ctx context.Context, viewName string, params map[string]string) ([]map[string]interface{}, error) {
+	viewConf, err := r.Provider.GetDataViewConfig(viewName)
+	if err != nil {
+		return nil, err
+	}
+	fetcher, err := r.fetcherFor(ctx, viewConf.DataSource)
+	if err != nil {
+		return nil, fmt.Errorf("data view '%s': %w", viewName, err)
+	}
+	return fetcher.FetchContext(ctx, viewName, params)
+}
+
+// fetcherFor returns the fetcher for a data source, opening it on first use.
+// Sources are opened outside of the lock, so that a slow source does not hold
+// up fetches from the others; concurrent fetches of a source being opened
+// wait for that open. Failed opens are not kept and are retried by later
+// fetches.
+func (r *RoutingDataFetcher) fetcherFor(ctx context.Context, sourceName string) (ContextDataFetcher, error) {
+	for {
+		r.mu.Lock()
+		if r.closed {
+			r.mu.Unlock()
+			return nil, errFetcherClosed
+		}
+		if fetcher, ok := r.fetchers[sourceName]; ok {
+			r.mu.Unlock()
+			return fetcher, nil
+		}
+		if pending, ok := r.opening[sourceName]; ok {
+			r.mu.Unlock()
+			select {
+			case <-pending.done:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			// Open again when the open only failed for its own fetch's context.
+			if pending.err != nil && isContextError(pending.err) && ctx.Err() == nil {
+				continue
+			}
+			return pending.fetcher, pending.err
+		}
+		pending := &pendingOpen{done: make(chan struct{})}
+		if r.opening == nil {
+			r.opening = make(map[string]*pendingOpen)
+		}
+		r.opening[sourceName] = pending
+		r.mu.Unlock()
+
+		var closer io.Closer
+		pending.fetcher, closer, pending.err = r.open(ctx, sourceName)
+
+		r.mu.Lock()
+		delete(r.opening, sourceName)
+		switch {
+		case pending.err != nil:
+		case r.closed:
+			// Closed while opening: release the source rather than keeping it
+			pending.fetcher, pending.err = nil, errFetcherClosed
+			if closer != nil {
+				if err := closer.Close(); err != nil {
+					pending.err = errors.Join(pending.err, fmt.Errorf("closing data source '%s': %w", sourceName, err))
+				}
+			}
+		default:
+			r.fetchers[sourceName] = pending.fetcher
+			if closer != nil {
+				r.closers[sourceName] = closer
+			}
+		}
+		r.mu.Unlock()
+		close(pending.done)
+		return pending.fetcher, pending.err
+	}
+}
+
+// pendingOpen is a data source being opened by fetcherFor.
+type pendingOpen struct {
+	done    chan struct{} // closed once fetcher and err are set
+	fetcher ContextDataFetcher
+	err     error
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// open creates the wrapped fetcher of a data source, and the fetcher to close
+// when it holds resources.
+func (r *RoutingDataFetcher) open(ctx context.Context, sourceName string) (ContextDataFetcher, io.Closer, error) {
+	source, err := r.Provider.GetDataSourceConfig(sourceName)
+	if err != nil {
+		return nil, nil, err
+	}
+	factory, ok := r.Factories[source.Driver]
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported driver '%s' for data source '%s'", source.Driver, source.Name)
+	}
+
+	slog.Info("Opening data source", "name", source.Name, "driver", source.Driver)
+	middlewares, err := r.middlewares(source)
+	if err != nil {
+		return nil, nil, err
+	}
+	fetcher, err := factory(ctx, source)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open data source '%s': %w", source.Name, err)
+	}
+	closer, _ := fetcher.(io.Closer)
+	return Chain(fetcher, middlewares...), closer, nil
+}
+
+// middlewares builds the middleware for a source from its FetchPolicy, outermost
+// first: metrics and logging see one fetch per view, retries wrap timed attempts.
+func (r *RoutingDataFetcher) middlewares(source *config.DataSourceConfig) ([]FetcherMiddleware, error) {
+	var middlewares []FetcherMiddleware
+	if r.Metrics != nil {
+		middlewares = append(middlewares, Metrics(r.Metrics))
+	}
+	policy := source.Fetch
+	if policy == nil {
+		return middlewares, nil
+	}
+
+	durations := make(map[string]time.Duration)
+	for name, value := range map[string]string{"retryBackoff": policy.RetryBackoff, "maxBackoff": policy.MaxBackoff, "timeout": policy.Timeout} {
+		if value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("data source '%s' fetch %s: %w", source.Name, name, err)
+		}
+		durations[name] = d
+	}
+
+	if policy.Log {
+		middlewares = append(middlewares, Logging(slog.Default().With("dataSource", source.Name)))
+	}
+	if policy.Retries > 0 {
+		middlewares = append(middlewares, Retry(RetryOptions{
+			MaxRetries:     policy.Retries,
+			InitialBackoff: durations["retryBackoff"],
+			MaxBackoff:     durations["maxBackoff"],
+		}))
+	}
+	if d, ok := durations["timeout"]; ok {
+		middlewares = append(middlewares, Timeout(d))
+	}
+	return middlewares, nil
+}
+
+// Close releases every opened fetcher that holds resources (e.g. database
+// connections). Sources still being opened are waited for and released when
+// their open completes; fetches after Close fail.
+func (r *RoutingDataFetcher) Close() error {
+	r.mu.Lock()
+	r.closed = true
+	pending := make([]*pendingOpen, 0, len(r.opening))
+	for _, p := range r.opening {
+		pending = append(pending, p)
+	}
+	r.mu.Unlock()
+	for _, p := range pending {
+		<-p.done
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var errs []error
+	for name, closer := range r.closers {
+		if err := closer.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("closing data source '%s': %w", name, err))
+		}
+	}
+	r.fetchers = make(map[string]ContextDataFetcher)
+	r.closers = make(map[string]io.Closer)
+	return errors.Join(errs...)
+}
+
+func (r *RoutingDataFetcher) openCsvSource(_ context.Context, source *config.DataSourceConfig) (DataFetcher, error) {
+	fetcher := NewCsvDataFetcher(source.DSN)
+	fetcher.Options = source.Csv
+	fetcher.Provider = r.Provider
+	return fetcher, nil
+}
+
+func (r *RoutingDataFetcher) openJsonSource(_ context.Context, source *config.DataSourceConfig) (DataFetcher, error) {
+	fetcher := NewJsonDataFetcher(source.DSN)
+	fetcher.Provider = r.Provider
+	return fetcher, nil
+}
+
+func (r *RoutingDataFetcher) openExcelSource(_ context.Context, source *config.DataSourceConfig) (DataFetcher, error) {
+	fetcher := NewExcelDataFetcher(source.DSN)
+	fetcher.Provider = r.Provider
+	return fetcher, nil
+}
+
+func (r *RoutingDataFetcher) openHttpSource(_ context.Context, source *config.DataSourceConfig) (DataFetcher, error) {
+	fetcher, err := NewHttpDataFetcher(source.DSN, source.Http)
+	if err != nil {
+		return nil, err
+	}
+	fetcher.Provider = r.Provider
+	return fetcher, nil
+}
+
+func (r *RoutingDataFetcher) openSQLSource(ctx context.Context, source *config.DataSourceConfig) (DataFetcher, error) {
+	db, err := sql.Open(source.Driver, source.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open db connection: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping db: %w", err)
+	}
+	fetcher := NewSQLDataFetcher(db, source.Driver)
+	fetcher.Provider = r.Provider
+	return fetcher, nil
+}
+
+func (r *RoutingDataFetcher) openDynamoDBSource(ctx context.Context, source *config.DataSourceConfig) (DataFetcher, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load AWS SDK config: %w", err)
+	}
+	fetcher := NewDynamoDBDataFetcher(cfg)
+	fetcher.Provider = r.Provider
+	return fetcher, nil
+}
@@ -0,0 +1,207 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fibr-gen/config"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestRoutingDataFetcher_DispatchesByDataSource(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "v_csv.csv"), []byte("id,name\n1,Alice\n"), 0644); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+
+	views := map[string]*config.DataViewConfig{
+		"v_csv":  {Name: "v_csv", DataSource: "files"},
+		"v_mock": {Name: "v_mock", DataSource: "warehouse"},
+	}
+	sources := map[string]*config.DataSourceConfig{
+		"files":     {Name: "files", Driver: "csv", DSN: dir},
+		"warehouse": {Name: "warehouse", Driver: "mock", DSN: "unused"},
+	}
+	router := NewRoutingDataFetcher(config.NewMemoryConfigRegistry(views, sources))
+
+	opened := 0
+	mock := &countingFetcher{data: map[string][]map[string]interface{}{
+		"v_mock": {{"ID": "42"}},
+	}}
+	router.Register("mock", func(_ context.Context, source *config.DataSourceConfig) (DataFetcher, error) {
+		opened++
+		return mock, nil
+	})
+
+	rows, err := router.Fetch("v_csv", nil)
+	if err != nil {
+		t.Fatalf("Fetch csv error: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["name"] != "Alice" {
+		t.Fatalf("csv rows = %v, want Alice", rows)
+	}
+
+	for range 2 {
+		rows, err = router.Fetch("v_mock", nil)
+		if err != nil {
+			t.Fatalf("Fetch mock error: %v", err)
+		}
+		if len(rows) != 1 || rows[0]["ID"] != "42" {
+			t.Fatalf("mock rows = %v, want ID 42", rows)
+		}
+	}
+	if opened != 1 {
+		t.Fatalf("factory opened %d times, want 1", opened)
+	}
+	if mock.calls != 2 {
+		t.Fatalf("mock fetcher calls = %d, want 2", mock.calls)
+	}
+	if err := router.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+}
+
+func TestRoutingDataFetcher_UnsupportedDriver(t *testing.T) {
+	views := map[string]*config.DataViewConfig{
+		"v1": {Name: "v1", DataSource: "ds1"},
+	}
+	sources := map[string]*config.DataSourceConfig{
+		"ds1": {Name: "ds1", Driver: "oracle", DSN: "x"},
+	}
+	router := NewRoutingDataFetcher(config.NewMemoryConfigRegistry(views, sources))
+	if _, err := router.Fetch("v1", nil); err == nil {
+		t.Fatal("expected error for unsupported driver")
+	}
+	if _, err := router.Fetch("missing", nil); err == nil {
+		t.Fatal("expected error for unknown view")
+	}
+}
+
+func TestRoutingDataFetcher_OpensSourcesWithFetchContext(t *testing.T) {
+	views := map[string]*config.DataViewConfig{
+		"v1": {Name: "v1", DataSource: "ds1"},
+	}
+	sources := map[string]*config.DataSourceConfig{
+		"ds1": {Name: "ds1", Driver: "slow", DSN: "x"},
+	}
+	router := NewRoutingDataFetcher(config.NewMemoryConfigRegistry(views, sources))
+	router.Register("slow", func(ctx context.Context, source *config.DataSourceConfig) (DataFetcher, error) {
+		<-ctx.Done() // e.g. loading credentials until interrupted
+		return nil, ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := router.FetchContext(ctx, "v1", nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("FetchContext error = %v, want context.Canceled", err)
+	}
+}
+
+func TestRoutingDataFetcher_SlowSourceDoesNotBlockOthers(t *testing.T) {
+	views := map[string]*config.DataViewConfig{
+		"v_slow": {Name: "v_slow", DataSource: "slow"},
+		"v_fast": {Name: "v_fast", DataSource: "fast"},
+	}
+	sources := map[string]*config.DataSourceConfig{
+		"slow": {Name: "slow", Driver: "slow", DSN: "x"},
+		"fast": {Name: "fast", Driver: "mock", DSN: "x"},
+	}
+	router := NewRoutingDataFetcher(config.NewMemoryConfigRegistry(views, sources))
+	opening := make(chan struct{})
+	release := make(chan struct{})
+	opens := 0
+	router.Register("slow", func(ctx context.Context, source *config.DataSourceConfig) (DataFetcher, error) {
+		opens++
+		close(opening)
+		<-release // e.g. a hanging connect
+		return &MockDataFetcher{Data: map[string][]map[string]interface{}{"v_slow": {{"id": 1}}}}, nil
+	})
+	router.Register("mock", func(ctx context.Context, source *config.DataSourceConfig) (DataFetcher, error) {
+		return &MockDataFetcher{Data: map[string][]map[string]interface{}{"v_fast": {{"id": 2}}}}, nil
+	})
+
+	var wg sync.WaitGroup
+	slowErrs := make([]error, 2)
+	for i := range slowErrs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, slowErrs[i] = router.Fetch("v_slow", nil)
+		}(i)
+	}
+	<-opening
+
+	if rows, err := router.Fetch("v_fast", nil); err != nil || len(rows) != 1 {
+		t.Fatalf("Fetch v_fast = %v, %v while another source is opening", rows, err)
+	}
+	close(release)
+	wg.Wait()
+	for _, err := range slowErrs {
+		if err != nil {
+			t.Fatalf("Fetch v_slow error: %v", err)
+		}
+	}
+	if opens != 1 {
+		t.Fatalf("slow source opened %d times, want 1", opens)
+	}
+}
+
+// closingFetcher records whether it was closed.
+type closingFetcher struct {
+	MockDataFetcher
+	closed bool
+}
+
+func (f *closingFetcher) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestRoutingDataFetcher_CloseReleasesPendingOpens(t *testing.T) {
+	views := map[string]*config.DataViewConfig{
+		"v_slow": {Name: "v_slow", DataSource: "slow"},
+	}
+	sources := map[string]*config.DataSourceConfig{
+		"slow": {Name: "slow", Driver: "slow", DSN: "x"},
+	}
+	router := NewRoutingDataFetcher(config.NewMemoryConfigRegistry(views, sources))
+	opening := make(chan struct{})
+	release := make(chan struct{})
+	fetcher := &closingFetcher{MockDataFetcher: MockDataFetcher{Data: map[string][]map[string]interface{}{"v_slow": {{"id": 1}}}}}
+	router.Register("slow", func(ctx context.Context, source *config.DataSourceConfig) (DataFetcher, error) {
+		close(opening)
+		<-release
+		return fetcher, nil
+	})
+
+	fetchErr := make(chan error)
+	go func() {
+		_, err := router.Fetch("v_slow", nil)
+		fetchErr <- err
+	}()
+	<-opening
+
+	closeErr := make(chan error)
+	go func() { closeErr <- router.Close() }()
+	for closed := false; !closed; {
+		router.mu.Lock()
+		closed = router.closed
+		router.mu.Unlock()
+	}
+	close(release)
+	if err := <-closeErr; err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+	// Close waits for the open, which releases the source it opened
+	if !fetcher.closed {
+		t.Fatal("source opened during Close was not closed")
+	}
+	if err := <-fetchErr; !errors.Is(err, errFetcherClosed) {
+		t.Fatalf("Fetch during Close error = %v, want %v", err, errFetcherClosed)
+	}
+	if _, err := router.Fetch("v_slow", nil); !errors.Is(err, errFetcherClosed) {
+		t.Fatalf("Fetch after Close error = %v, want %v", err, errFetcherClosed)
+	}
+}
@@ -30,10 +30,18 @@ func NewS3Uploader(cfg aws.Config, bucket, prefix string) *S3Uploader {
 
 // UploadDirectory walks the local directory and uploads all files to S3.
 func (u *S3Uploader) UploadDirectory(localDir string) error {
+	return u.UploadDirectoryContext(context.Background(), localDir)
+}
+
+// UploadDirectoryContext is UploadDirectory, stopping when ctx is cancelled.
+func (u *S3Uploader) UploadDirectoryContext(ctx context.Context, localDir string) error {
 	return filepath.Walk(localDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		if info.IsDir() {
 			return nil
 		}
@@ -51,12 +59,17 @@ func (u *S3Uploader) UploadDirectory(localDir string) error {
 		// Remove leading slash if any
 		key = strings.TrimPrefix(key, "/")
 
-		return u.UploadFile(path, key)
+		return u.UploadFileContext(ctx, path, key)
 	})
 }
 
 // UploadFile uploads a single file to S3.
 func (u *S3Uploader) UploadFile(localPath, key string) error {
+	return u.UploadFileContext(context.Background(), localPath, key)
+}
+
+// UploadFileContext is UploadFile with the request bound to ctx.
+func (u *S3Uploader) UploadFileContext(ctx context.Context, localPath, key string) error {
 	file, err := os.Open(localPath)
 	if err != nil {
 		return fmt.Errorf("failed to open file %s: %w", localPath, err)
@@ -65,7 +78,7 @@ func (u *S3Uploader) UploadFile(localPath, key string) error {
 
 	slog.Info("Uploading to S3", "local", localPath, "bucket", u.Bucket, "key", key)
 
-	_, err = u.Client.PutObject(context.TODO(), &s3.PutObjectInput{
+	_, err = u.Client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket: aws.String(u.Bucket),
 		Key:    aws.String(key),
 		Body:   file,
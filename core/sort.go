@@ -0,0 +1,194 @@
+package core
+
+import (
+	"cmp"
+	"fibr-gen/config"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// sortColumn holds the values of one sort key for every row being sorted.
+type sortColumn struct {
+	values []interface{} // coerced values, nil for nulls
+	ranks  []int         // position of each value in the custom order, -1 if unlisted
+	desc   bool
+}
+
+// compare orders rows i and j in the key's direction: values listed in the
+// custom order by their position, then the others by value. Listed values
+// come before the others and nulls last, in either direction.
+func (c *sortColumn) compare(i, j int) int {
+	a, b := c.values[i], c.values[j]
+	if a == nil || b == nil {
+		return cmp.Compare(boolRank(a == nil), boolRank(b == nil))
+	}
+	var order int
+	if c.ranks != nil {
+		ra, rb := c.ranks[i], c.ranks[j]
+		switch {
+		case ra >= 0 && rb >= 0:
+			order = cmp.Compare(ra, rb)
+		case ra >= 0:
+			return -1
+		case rb >= 0:
+			return 1
+		default:
+			order = compareSortValues(a, b)
+		}
+	} else {
+		order = compareSortValues(a, b)
+	}
+	if c.desc {
+		return -order
+	}
+	return order
+}
+
+// sortRows returns rows stable-sorted by the sort keys; rows itself is not reordered.
+func sortRows(conf *config.DataViewConfig, rows []map[string]interface{}, keys []config.SortConfig) ([]map[string]interface{}, error) {
+	if len(keys) == 0 || len(rows) < 2 {
+		return rows, nil
+	}
+
+	columns := make([]*sortColumn, len(keys))
+	for k, key := range keys {
+		var label *config.LabelConfig
+		for i := range conf.Labels {
+			if conf.Labels[i].Name == key.Label {
+				label = &conf.Labels[i]
+				break
+			}
+		}
+		if label == nil {
+			return nil, fmt.Errorf("sort label '%s' not found in view '%s'", key.Label, conf.Name)
+		}
+
+		raw := make([]interface{}, len(rows))
+		for i, row := range rows {
+			raw[i] = row[label.Column]
+		}
+		t := key.Type
+		if t == "" {
+			t = label.Type
+		}
+		column, err := newSortColumn(raw, t, key.Order)
+		if err != nil {
+			return nil, fmt.Errorf("cannot sort label '%s' of view '%s': %w", key.Label, conf.Name, err)
+		}
+		column.desc = key.Direction == config.SortDesc
+		columns[k] = column
+	}
+
+	order := make([]int, len(rows))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(i, j int) int {
+		for _, column := range columns {
+			if c := column.compare(i, j); c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+
+	sorted := make([]map[string]interface{}, len(rows))
+	for i, pos := range order {
+		sorted[i] = rows[pos]
+	}
+	return sorted, nil
+}
+
+// sortLabelValues sorts label values in place, numerically or chronologically
+// when they all read as numbers or dates, and as text otherwise.
+func sortLabelValues(values []string) {
+	raw := make([]interface{}, len(values))
+	for i, v := range values {
+		raw[i] = v
+	}
+	column, _ := newSortColumn(raw, "", nil) // Inferred types always coerce
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, column.compare)
+
+	sorted := make([]string, len(values))
+	for i, pos := range order {
+		sorted[i] = values[pos]
+	}
+	copy(values, sorted)
+}
+
+// newSortColumn coerces raw values to type t and ranks them in the custom order.
+// Without a type, values compare as numbers if they all are (or read as)
+// numbers, else as dates if they all are dates, else as text.
+func newSortColumn(raw []interface{}, t config.LabelType, customOrder []string) (*sortColumn, error) {
+	column := &sortColumn{}
+	if t == "" {
+		for _, candidate := range []config.LabelType{config.LabelTypeDecimal, config.LabelTypeDateTime, config.LabelTypeString} {
+			if values, err := coerceSortValues(raw, candidate); err == nil {
+				column.values = values
+				break
+			}
+		}
+	} else {
+		values, err := coerceSortValues(raw, t)
+		if err != nil {
+			return nil, err
+		}
+		column.values = values
+	}
+
+	if len(customOrder) > 0 {
+		positions := make(map[string]int, len(customOrder))
+		for i, v := range customOrder {
+			positions[v] = i
+		}
+		column.ranks = make([]int, len(raw))
+		for i, v := range raw {
+			column.ranks[i] = -1
+			if pos, ok := positions[formatLabelValue("", v)]; ok && v != nil {
+				column.ranks[i] = pos
+			}
+		}
+	}
+	return column, nil
+}
+
+func coerceSortValues(raw []interface{}, t config.LabelType) ([]interface{}, error) {
+	values := make([]interface{}, len(raw))
+	for i, v := range raw {
+		c, err := CoerceLabelValue(t, v)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = c
+	}
+	return values, nil
+}
+
+// compareSortValues compares two non-nil values coerced to the same type.
+func compareSortValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case int64:
+		return cmp.Compare(a, b.(int64))
+	case float64:
+		return cmp.Compare(a, b.(float64))
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	case bool:
+		return cmp.Compare(boolRank(a), boolRank(b.(bool)))
+	default:
+		return strings.Compare(fmt.Sprintf("%v", a), fmt.Sprintf("%v", b))
+	}
+}
+
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
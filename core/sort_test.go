@@ -0,0 +1,129 @@
+package core
+
+import (
+	"fibr-gen/config"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestSortRows(t *testing.T) {
+	conf := &config.DataViewConfig{
+		Name: "v_sales",
+		Labels: []config.LabelConfig{
+			{Name: "month", Column: "MONTH"},
+			{Name: "region", Column: "REGION"},
+			{Name: "amount", Column: "AMOUNT"},
+			{Name: "day", Column: "DAY"},
+		},
+	}
+	rows := []map[string]interface{}{
+		{"ID": 1, "MONTH": "Mar", "REGION": "EU", "AMOUNT": "10", "DAY": time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)},
+		{"ID": 2, "MONTH": "Jan", "REGION": "US", "AMOUNT": "2", "DAY": time.Date(2025, 1, 9, 0, 0, 0, 0, time.UTC)},
+		{"ID": 3, "MONTH": "Feb", "REGION": "EU", "AMOUNT": nil, "DAY": nil},
+		{"ID": 4, "MONTH": "Jan", "REGION": "EU", "AMOUNT": "2.5", "DAY": time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC)},
+	}
+	months := []string{"Jan", "Feb", "Mar"}
+
+	tests := []struct {
+		name string
+		keys []config.SortConfig
+		want []int
+	}{
+		{"Numeric text, nulls last", []config.SortConfig{{Label: "amount"}}, []int{2, 4, 1, 3}},
+		{"Descending, nulls still last", []config.SortConfig{{Label: "amount", Direction: config.SortDesc}}, []int{1, 4, 2, 3}},
+		{"Text", []config.SortConfig{{Label: "month"}}, []int{3, 2, 4, 1}},
+		{"Custom order", []config.SortConfig{{Label: "month", Order: months}}, []int{2, 4, 3, 1}},
+		{"Dates", []config.SortConfig{{Label: "day"}}, []int{4, 2, 1, 3}},
+		{"Two keys", []config.SortConfig{{Label: "region"}, {Label: "month", Order: months, Direction: config.SortDesc}}, []int{1, 3, 4, 2}},
+		{"Explicit text type", []config.SortConfig{{Label: "amount", Type: config.LabelTypeString}}, []int{1, 2, 4, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorted, err := sortRows(conf, rows, tt.keys)
+			if err != nil {
+				t.Fatalf("sortRows error: %v", err)
+			}
+			var got []int
+			for _, row := range sorted {
+				got = append(got, row["ID"].(int))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if rows[0]["ID"] != 1 {
+		t.Fatal("sortRows reordered its input")
+	}
+	if _, err := sortRows(conf, rows, []config.SortConfig{{Label: "month", Type: config.LabelTypeInt}}); err == nil {
+		t.Fatal("expected error sorting month names as int")
+	}
+	if _, err := sortRows(conf, rows, []config.SortConfig{{Label: "unknown"}}); err == nil {
+		t.Fatal("expected error for unknown sort label")
+	}
+}
+
+func TestSortLabelValues(t *testing.T) {
+	tests := []struct {
+		values []string
+		want   []string
+	}{
+		{[]string{"10", "2", "1.5"}, []string{"1.5", "2", "10"}},
+		{[]string{"2025-10-01", "2025-9-1", "2025-02-01"}, []string{"2025-02-01", "2025-10-01", "2025-9-1"}}, // not all dates: text
+		{[]string{"2025-10-01", "2025-02-01"}, []string{"2025-02-01", "2025-10-01"}},
+		{[]string{"b", "10", "a"}, []string{"10", "a", "b"}},
+	}
+	for _, tt := range tests {
+		sortLabelValues(tt.values)
+		if !reflect.DeepEqual(tt.values, tt.want) {
+			t.Errorf("sortLabelValues = %v, want %v", tt.values, tt.want)
+		}
+	}
+}
+
+func TestDynamicSheet_Sort(t *testing.T) {
+	views := map[string]*config.DataViewConfig{
+		"v_months": {
+			Name: "v_months",
+			Labels: []config.LabelConfig{
+				{Name: "month_name", Column: "NAME"},
+				{Name: "month_no", Column: "NO"},
+			},
+		},
+	}
+	wbConfig := &config.WorkbookConfig{
+		Sheets: []config.SheetConfig{
+			{
+				Name:         "Sheet",
+				Dynamic:      true,
+				DataViewName: "v_months",
+				ParamLabel:   "month_name",
+				Sort:         []config.SortConfig{{Label: "month_no"}},
+			},
+		},
+	}
+	fetcher := &MockFetcher{Data: map[string][]map[string]interface{}{
+		"v_months": {
+			{"NAME": "Oct", "NO": 10},
+			{"NAME": "Feb", "NO": 2},
+			{"NAME": "Jan", "NO": 1},
+		},
+	}}
+	ctx := NewGenerationContext(wbConfig, config.NewMemoryConfigRegistry(views, nil), fetcher, nil)
+	gen := NewGenerator(ctx)
+
+	f := excelize.NewFile()
+	f.SetSheetName("Sheet1", "Sheet")
+	if err := gen.processSheet(&ExcelizeFile{file: f}, &wbConfig.Sheets[0]); err != nil {
+		t.Fatalf("processSheet failed: %v", err)
+	}
+
+	want := []string{"Jan", "Feb", "Oct"}
+	if got := f.GetSheetList(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("sheets = %v, want %v", got, want)
+	}
+}
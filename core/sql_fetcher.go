@@ -1,16 +1,20 @@
 package core
 
 import (
+	"context"
 	"database/sql"
+	"fibr-gen/config"
 	"fmt"
 	"strings"
 )
 
-// SQLDataFetcher implements DataFetcher using a generic SQL database (MySQL, PostgreSQL).
-// It maps viewName to a Table Name.
+// SQLDataFetcher implements DataFetcher using a generic SQL database (MySQL, PostgreSQL, SQLite).
+// It runs the view's configured Sql if any, otherwise it reads the view's Table
+// (defaulting to viewName).
 type SQLDataFetcher struct {
 	DB         *sql.DB
-	DriverName string // "mysql" or "postgres"
+	DriverName string          // "mysql", "postgres" or "sqlite"
+	Provider   config.Provider // Optional: resolves view configs (Sql, Table)
 }
 
 // NewSQLDataFetcher creates a new fetcher.
@@ -21,30 +25,27 @@ func NewSQLDataFetcher(db *sql.DB, driverName string) *SQLDataFetcher {
 	}
 }
 
-// Fetch executes a SELECT query on the table specified by viewName.
-// It applies simple equality filtering based on params.
+// Close closes the underlying database connection pool.
+func (f *SQLDataFetcher) Close() error {
+	return f.DB.Close()
+}
+
+// Fetch executes the view's query.
+// If the view config has Sql, it is run with its :name references bound from params.
+// Otherwise it selects from the view's table, applying equality filtering for
+// params that map to the view's labels and the view's Filters.
 func (f *SQLDataFetcher) Fetch(viewName string, params map[string]string) ([]map[string]interface{}, error) {
-	tableName := viewName
-	query := fmt.Sprintf("SELECT * FROM %s", tableName)
-	var args []interface{}
+	return f.FetchContext(context.Background(), viewName, params)
+}
 
-	if len(params) > 0 {
-		var conditions []string
-		i := 1
-		for k, v := range params {
-			if f.DriverName == "postgres" {
-				conditions = append(conditions, fmt.Sprintf("%s = $%d", k, i))
-			} else {
-				// MySQL and others usually use ?
-				conditions = append(conditions, fmt.Sprintf("%s = ?", k))
-			}
-			args = append(args, v)
-			i++
-		}
-		query += " WHERE " + strings.Join(conditions, " AND ")
+// FetchContext is Fetch with the query bound to ctx.
+func (f *SQLDataFetcher) FetchContext(ctx context.Context, viewName string, params map[string]string) ([]map[string]interface{}, error) {
+	query, args, err := f.buildQuery(viewName, params)
+	if err != nil {
+		return nil, err
 	}
 
-	rows, err := f.DB.Query(query, args...)
+	rows, err := f.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query failed: %w", err)
 	}
@@ -55,6 +56,10 @@ func (f *SQLDataFetcher) Fetch(viewName string, params map[string]string) ([]map
 	if err != nil {
 		return nil, fmt.Errorf("failed to get columns: %w", err)
 	}
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get column types: %w", err)
+	}
 
 	var result []map[string]interface{}
 
@@ -72,13 +77,7 @@ func (f *SQLDataFetcher) Fetch(viewName string, params map[string]string) ([]map
 
 		entry := make(map[string]interface{})
 		for i, col := range columns {
-			val := values[i]
-			// Handle []byte (MySQL driver often returns strings as []byte)
-			if b, ok := val.([]byte); ok {
-				entry[col] = string(b)
-			} else {
-				entry[col] = val
-			}
+			entry[col] = f.normalizeValue(columnTypes[i].DatabaseTypeName(), values[i])
 		}
 		result = append(result, entry)
 	}
@@ -89,3 +88,271 @@ func (f *SQLDataFetcher) Fetch(viewName string, params map[string]string) ([]map
 
 	return result, nil
 }
+
+// normalizeValue maps a scanned value to the Go type reports expect.
+func (f *SQLDataFetcher) normalizeValue(dbType string, val interface{}) interface{} {
+	// Handle []byte (MySQL driver often returns strings as []byte)
+	if b, ok := val.([]byte); ok {
+		val = string(b)
+	}
+	if f.DriverName != "sqlite" || val == nil {
+		return val
+	}
+
+	// SQLite stores values dynamically; use the declared column type to restore
+	// booleans (stored as 0 / 1) and dates the driver left as text.
+	dbType = strings.ToUpper(dbType)
+	switch {
+	case strings.Contains(dbType, "BOOL"):
+		if n, ok := val.(int64); ok {
+			return n != 0
+		}
+	case strings.Contains(dbType, "DATE") || strings.Contains(dbType, "TIME"):
+		if s, ok := val.(string); ok {
+			if tm, err := coerceTime(s); err == nil {
+				return tm
+			}
+		}
+	}
+	return val
+}
+
+// buildQuery returns the SQL statement and bind arguments for a view.
+func (f *SQLDataFetcher) buildQuery(viewName string, params map[string]string) (string, []interface{}, error) {
+	conf, err := lookupViewConfig(f.Provider, viewName)
+	if err != nil {
+		return "", nil, err
+	}
+	if conf != nil && conf.Sql != "" {
+		query, args, err := bindNamedParams(conf.Sql, f.DriverName, params)
+		if err != nil {
+			return "", nil, fmt.Errorf("data view '%s': %w", viewName, err)
+		}
+		return query, args, nil
+	}
+
+	tableName := physicalName(viewName, conf)
+	query := fmt.Sprintf("SELECT * FROM %s", quoteQualifiedIdentifier(f.DriverName, tableName))
+	var args []interface{}
+
+	filters := pushdownParams(conf, params)
+	extra, err := viewFilters(conf, params)
+	if err != nil {
+		return "", nil, err
+	}
+	filters = append(filters, extra...)
+	if len(filters) > 0 {
+		var conditions []string
+		for _, filter := range filters {
+			conditions = append(conditions, f.condition(filter, &args))
+		}
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	return query, args, nil
+}
+
+// sqlOperators maps comparison filter operators to SQL.
+var sqlOperators = map[config.FilterOp]string{
+	config.FilterOpEq: "=",
+	config.FilterOpNe: "<>",
+	config.FilterOpLt: "<",
+	config.FilterOpLe: "<=",
+	config.FilterOpGt: ">",
+	config.FilterOpGe: ">=",
+}
+
+// condition renders a filter as a SQL condition, appending its bound values to args.
+func (f *SQLDataFetcher) condition(filter columnFilter, args *[]interface{}) string {
+	column := quoteIdentifier(f.DriverName, filter.Column)
+	placeholder := func(value string) string {
+		*args = append(*args, value)
+		if f.DriverName == "postgres" {
+			return fmt.Sprintf("$%d", len(*args))
+		}
+		// MySQL, SQLite and others usually use ?
+		return "?"
+	}
+
+	switch op := filter.op(); op {
+	case config.FilterOpNull:
+		return column + " IS NULL"
+	case config.FilterOpNotNull:
+		return column + " IS NOT NULL"
+	case config.FilterOpIn:
+		if len(filter.Values) == 0 {
+			return "1 = 0"
+		}
+		placeholders := make([]string, len(filter.Values))
+		for i, value := range filter.Values {
+			placeholders[i] = placeholder(value)
+		}
+		return fmt.Sprintf("%s IN (%s)", column, strings.Join(placeholders, ", "))
+	case config.FilterOpPrefix, config.FilterOpContains:
+		pattern := escapeLike(filter.Value) + "%"
+		if op == config.FilterOpContains {
+			pattern = "%" + pattern
+		}
+		return fmt.Sprintf("%s LIKE %s ESCAPE '!'", column, placeholder(pattern))
+	default:
+		return fmt.Sprintf("%s %s %s", column, sqlOperators[op], placeholder(filter.Value))
+	}
+}
+
+// escapeLike escapes LIKE wildcards with '!', which needs no escaping in any dialect's literals.
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
+
+// quoteIdentifier quotes a column or table name for the driver's SQL dialect,
+// escaping embedded quote characters so names can never break out of the identifier.
+func quoteIdentifier(driverName, name string) string {
+	switch driverName {
+	case "mysql":
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	case "sqlserver":
+		return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+	default:
+		return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+	}
+}
+
+// quoteQualifiedIdentifier quotes each part of a dotted name such as "schema.table".
+func quoteQualifiedIdentifier(driverName, name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = quoteIdentifier(driverName, part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// bindNamedParams rewrites :name references in query into the driver's placeholder
+// style ("$n" for postgres, "?" otherwise) and returns the bound values from params.
+// References inside quoted strings, quoted identifiers and comments are left untouched,
+// as are postgres "::" casts, dollar-quoted strings ($$...$$, $tag$...$tag$) and the
+// bound separators of array slices (arr[1:n]; a ':' right after '[' is a reference).
+func bindNamedParams(query, driverName string, params map[string]string) (string, []interface{}, error) {
+	var (
+		out      strings.Builder
+		args     []interface{}
+		indexes  = make(map[string]int) // postgres: reuse $n for repeated names
+		brackets int                    // postgres: depth of array subscripts
+	)
+
+	n := len(query)
+	for i := 0; i < n; i++ {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			// Quoted literal or identifier: copy through to the closing quote.
+			end := i + 1
+			for end < n {
+				if query[end] == '\\' && c == '\'' && driverName == "mysql" {
+					end += 2
+					continue
+				}
+				if query[end] == c {
+					if end+1 < n && query[end+1] == c { // doubled quote escape
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			end = min(end, n-1)
+			out.WriteString(query[i : end+1])
+			i = end
+		case c == '-' && i+1 < n && query[i+1] == '-':
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = n - i - 1
+			}
+			out.WriteString(query[i : i+end+1])
+			i += end
+		case c == '/' && i+1 < n && query[i+1] == '*':
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				end = n - i - 4
+			}
+			out.WriteString(query[i : i+end+4])
+			i += end + 3
+		case c == '$' && driverName == "postgres" && dollarQuoteTag(query, i) != "":
+			// Dollar-quoted string: copy through to the closing tag.
+			tag := dollarQuoteTag(query, i)
+			end := strings.Index(query[i+len(tag):], tag)
+			if end < 0 {
+				end = n - i - 2*len(tag)
+			}
+			out.WriteString(query[i : i+end+2*len(tag)])
+			i += end + 2*len(tag) - 1
+		case (c == '[' || c == ']') && driverName == "postgres":
+			if c == '[' {
+				brackets++
+			} else if brackets > 0 {
+				brackets--
+			}
+			out.WriteByte(c)
+		case c == ':' && i+1 < n && query[i+1] == ':':
+			out.WriteString("::")
+			i++
+		case c == ':' && brackets > 0 && !strings.HasSuffix(strings.TrimRight(query[:i], " \t\r\n"), "["):
+			// Array slice bound separator
+			out.WriteByte(c)
+		case c == ':' && i+1 < n && isParamNameStart(query[i+1]):
+			end := i + 1
+			for end < n && isParamNameChar(query[end]) {
+				end++
+			}
+			name := query[i+1 : end]
+			val, ok := params[name]
+			if !ok {
+				return "", nil, fmt.Errorf("sql parameter ':%s' is not set", name)
+			}
+			if driverName == "postgres" {
+				idx, seen := indexes[name]
+				if !seen {
+					args = append(args, val)
+					idx = len(args)
+					indexes[name] = idx
+				}
+				fmt.Fprintf(&out, "$%d", idx)
+			} else {
+				args = append(args, val)
+				out.WriteByte('?')
+			}
+			i = end - 1
+		default:
+			out.WriteByte(c)
+		}
+	}
+
+	return out.String(), args, nil
+}
+
+// dollarQuoteTag returns the opening tag ("$$" or "$tag$") of a postgres
+// dollar-quoted string at query[i], or "" if there is none. A '$' inside an
+// identifier or starting a "$n" placeholder does not open one.
+func dollarQuoteTag(query string, i int) string {
+	if i > 0 && isParamNameChar(query[i-1]) {
+		return ""
+	}
+	end := i + 1
+	if end < len(query) && isParamNameStart(query[end]) {
+		for end < len(query) && isParamNameChar(query[end]) {
+			end++
+		}
+	}
+	if end >= len(query) || query[end] != '$' {
+		return ""
+	}
+	return query[i : end+1]
+}
+
+func isParamNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isParamNameChar(c byte) bool {
+	return isParamNameStart(c) || (c >= '0' && c <= '9')
+}
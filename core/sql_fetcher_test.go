@@ -0,0 +1,272 @@
+package core
+
+import (
+	"database/sql"
+	"fibr-gen/config"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestBindNamedParams(t *testing.T) {
+	params := map[string]string{
+		"start_date": "2025-01-01",
+		"end_date":   "2025-01-31",
+		"region":     "EU",
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		driver    string
+		wantQuery string
+		wantArgs  []interface{}
+		wantErr   bool
+	}{
+		{
+			name:      "MySQL placeholders",
+			query:     "SELECT * FROM sales WHERE d >= :start_date AND d <= :end_date",
+			driver:    "mysql",
+			wantQuery: "SELECT * FROM sales WHERE d >= ? AND d <= ?",
+			wantArgs:  []interface{}{"2025-01-01", "2025-01-31"},
+		},
+		{
+			name:      "Postgres reuses index for repeated names",
+			query:     "SELECT :region AS r, d::date FROM s WHERE region = :region AND d >= :start_date",
+			driver:    "postgres",
+			wantQuery: "SELECT $1 AS r, d::date FROM s WHERE region = $1 AND d >= $2",
+			wantArgs:  []interface{}{"EU", "2025-01-01"},
+		},
+		{
+			name:      "Quoted text and comments are untouched",
+			query:     "SELECT ':region', \":x\" -- :y\nFROM s /* :z */ WHERE r = :region",
+			driver:    "mysql",
+			wantQuery: "SELECT ':region', \":x\" -- :y\nFROM s /* :z */ WHERE r = ?",
+			wantArgs:  []interface{}{"EU"},
+		},
+		{
+			name:      "Postgres dollar-quoted strings are untouched",
+			query:     "SELECT $$:region$$, $fn$ SELECT :x $fn$, a$b FROM s WHERE r = :region",
+			driver:    "postgres",
+			wantQuery: "SELECT $$:region$$, $fn$ SELECT :x $fn$, a$b FROM s WHERE r = $1",
+			wantArgs:  []interface{}{"EU"},
+		},
+		{
+			name:      "Postgres array slices are untouched",
+			query:     "SELECT arr[1:n], arr[lo : hi][:region], arr[:region:n] FROM s",
+			driver:    "postgres",
+			wantQuery: "SELECT arr[1:n], arr[lo : hi][$1], arr[$1:n] FROM s",
+			wantArgs:  []interface{}{"EU"},
+		},
+		{
+			name:    "Missing parameter",
+			query:   "SELECT * FROM s WHERE r = :unknown",
+			driver:  "mysql",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := bindNamedParams(tt.query, tt.driver, params)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("bindNamedParams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if query != tt.wantQuery {
+				t.Errorf("query = %q, want %q", query, tt.wantQuery)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestSQLDataFetcher_BuildQueryUsesViewSql(t *testing.T) {
+	views := map[string]*config.DataViewConfig{
+		"v_sales": {
+			Name: "v_sales",
+			Sql:  "WITH m AS (SELECT * FROM sales WHERE month = :month) SELECT region, SUM(amount) AS total FROM m GROUP BY region",
+		},
+	}
+	fetcher := NewSQLDataFetcher(nil, "postgres")
+	fetcher.Provider = config.NewMemoryConfigRegistry(views, nil)
+
+	query, args, err := fetcher.buildQuery("v_sales", map[string]string{"month": "2025-01", "env": "dev"})
+	if err != nil {
+		t.Fatalf("buildQuery error: %v", err)
+	}
+	want := "WITH m AS (SELECT * FROM sales WHERE month = $1) SELECT region, SUM(amount) AS total FROM m GROUP BY region"
+	if query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"2025-01"}) {
+		t.Errorf("args = %v, want [2025-01]", args)
+	}
+}
+
+func TestSQLDataFetcher_BuildQueryUsesViewTable(t *testing.T) {
+	views := map[string]*config.DataViewConfig{
+		"monthly_sales_eu": {Name: "monthly_sales_eu", Table: "sales"},
+	}
+	fetcher := NewSQLDataFetcher(nil, "mysql")
+	fetcher.Provider = config.NewMemoryConfigRegistry(views, nil)
+
+	query, _, err := fetcher.buildQuery("monthly_sales_eu", nil)
+	if err != nil {
+		t.Fatalf("buildQuery error: %v", err)
+	}
+	if query != "SELECT * FROM `sales`" {
+		t.Errorf("query = %q, want SELECT * FROM `sales`", query)
+	}
+}
+
+func TestSQLDataFetcher_BuildQueryPushesDownLabelParams(t *testing.T) {
+	views := map[string]*config.DataViewConfig{
+		"v_emp": {
+			Name:  "v_emp",
+			Table: "hr.employees",
+			Labels: []config.LabelConfig{
+				{Name: "dept", Column: "DEPT_CD"},
+				{Name: "month", Column: "MONTH_ID"},
+				{Name: "name", Column: "USER_NAME"},
+			},
+		},
+	}
+	fetcher := NewSQLDataFetcher(nil, "postgres")
+	fetcher.Provider = config.NewMemoryConfigRegistry(views, nil)
+
+	query, args, err := fetcher.buildQuery("v_emp", map[string]string{
+		"env":   "dev",
+		"month": "2025-01",
+		"dept":  "D1",
+	})
+	if err != nil {
+		t.Fatalf("buildQuery error: %v", err)
+	}
+	want := `SELECT * FROM "hr"."employees" WHERE "DEPT_CD" = $1 AND "MONTH_ID" = $2`
+	if query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"D1", "2025-01"}) {
+		t.Errorf("args = %v, want [D1 2025-01]", args)
+	}
+}
+
+func TestSQLDataFetcher_BuildQueryPushesDownViewFilters(t *testing.T) {
+	views := map[string]*config.DataViewConfig{
+		"v_orders": {
+			Name: "v_orders",
+			Labels: []config.LabelConfig{
+				{Name: "date", Column: "ORDER_DATE", Type: config.LabelTypeDate},
+				{Name: "status", Column: "STATUS"},
+				{Name: "name", Column: "NAME"},
+				{Name: "note", Column: "NOTE"},
+				{Name: "region", Column: "REGION"},
+			},
+			Filters: []config.FilterConfig{
+				{Label: "date", Op: config.FilterOpGe, Value: "${start}"},
+				{Label: "date", Op: config.FilterOpLt, Value: "${end}"}, // skipped: end is unset
+				{Label: "status", Op: config.FilterOpIn, Value: "${statuses}"},
+				{Label: "name", Op: config.FilterOpPrefix, Value: "100%_"},
+				{Label: "note", Op: config.FilterOpNull},
+			},
+		},
+	}
+	fetcher := NewSQLDataFetcher(nil, "postgres")
+	fetcher.Provider = config.NewMemoryConfigRegistry(views, nil)
+
+	query, args, err := fetcher.buildQuery("v_orders", map[string]string{
+		"region":   "EU",
+		"start":    "2025-01-01",
+		"statuses": "open, paid",
+	})
+	if err != nil {
+		t.Fatalf("buildQuery error: %v", err)
+	}
+	want := `SELECT * FROM "v_orders" WHERE "REGION" = $1 AND "ORDER_DATE" >= $2 AND "STATUS" IN ($3, $4) AND "NAME" LIKE $5 ESCAPE '!' AND "NOTE" IS NULL`
+	if query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+	if wantArgs := []interface{}{"EU", "2025-01-01", "open", "paid", "100!%!_%"}; !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("args = %v, want %v", args, wantArgs)
+	}
+
+	if _, _, err := fetcher.buildQuery("v_orders", map[string]string{"start": "soon"}); err == nil {
+		t.Fatal("expected error for a filter value that is not a date")
+	}
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	tests := []struct {
+		driver string
+		name   string
+		want   string
+	}{
+		{"mysql", "id` = 1; DROP TABLE t; --", "`id`` = 1; DROP TABLE t; --`"},
+		{"postgres", `id" = 1; --`, `"id"" = 1; --"`},
+		{"sqlserver", "id] = 1", "[id]] = 1]"},
+	}
+	for _, tt := range tests {
+		if got := quoteIdentifier(tt.driver, tt.name); got != tt.want {
+			t.Errorf("quoteIdentifier(%s, %q) = %q, want %q", tt.driver, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSQLDataFetcher_FetchSQLite(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "report.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`
+		CREATE TABLE payments (dept TEXT, amount REAL, qty INTEGER, paid BOOLEAN, paid_on DATE, note BLOB);
+		INSERT INTO payments VALUES ('D1', 12.5, 3, 1, '2025-03-14', x'6869');
+		INSERT INTO payments VALUES ('D2', 7.25, 1, 0, '2025-03-15', NULL);
+	`); err != nil {
+		t.Fatalf("seed sqlite: %v", err)
+	}
+
+	views := map[string]*config.DataViewConfig{
+		"v_paid": {Name: "v_paid", Table: "payments", Labels: []config.LabelConfig{{Name: "dept", Column: "dept"}}},
+		"v_sum":  {Name: "v_sum", Sql: "SELECT dept, SUM(amount) AS total FROM payments WHERE qty >= :min_qty GROUP BY dept"},
+	}
+	fetcher := NewSQLDataFetcher(db, "sqlite")
+	fetcher.Provider = config.NewMemoryConfigRegistry(views, nil)
+
+	rows, err := fetcher.Fetch("v_paid", map[string]string{"dept": "D1"})
+	if err != nil {
+		t.Fatalf("Fetch v_paid error: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("v_paid rows = %v, want 1", rows)
+	}
+	want := map[string]interface{}{
+		"dept":    "D1",
+		"amount":  12.5,
+		"qty":     int64(3),
+		"paid":    true,
+		"paid_on": time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC),
+		"note":    "hi",
+	}
+	for k, v := range want {
+		if got := rows[0][k]; !reflect.DeepEqual(got, v) {
+			t.Errorf("%s = %#v, want %#v", k, got, v)
+		}
+	}
+
+	rows, err = fetcher.Fetch("v_sum", map[string]string{"min_qty": "2"})
+	if err != nil {
+		t.Fatalf("Fetch v_sum error: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["total"] != 12.5 {
+		t.Fatalf("v_sum rows = %v, want D1 total 12.5", rows)
+	}
+}
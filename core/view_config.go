@@ -0,0 +1,129 @@
+package core
+
+import (
+	"fibr-gen/config"
+	"regexp"
+	"sort"
+)
+
+// lookupViewConfig resolves a view's config through provider.
+// It returns nil without error when no provider is configured, so fetchers
+// can still be used standalone with viewName as the physical name.
+func lookupViewConfig(provider config.Provider, viewName string) (*config.DataViewConfig, error) {
+	if provider == nil {
+		return nil, nil
+	}
+	return provider.GetDataViewConfig(viewName)
+}
+
+// physicalName returns the table (or file / DynamoDB table) a view reads from.
+// It is the view's Table if set, otherwise the view name itself.
+func physicalName(viewName string, conf *config.DataViewConfig) string {
+	if conf != nil && conf.Table != "" {
+		return conf.Table
+	}
+	return viewName
+}
+
+// columnFilter is a filter on a physical column: an equality filter for
+// parameters, or any operator of a config.FilterConfig.
+type columnFilter struct {
+	Column string
+	Op     config.FilterOp // empty for equality
+	Value  string
+	Values []string         // operands of config.FilterOpIn
+	Type   config.LabelType // type of the label the filter came from, if any
+}
+
+// pushdownParams translates parameters into column filters through the view's
+// label mapping. Only parameters named after a label of the view are kept, so
+// global parameters such as "env" never reach the data source. Without a view
+// config (standalone use) parameter names are taken as column names.
+// Filters are sorted by column for deterministic queries.
+func pushdownParams(conf *config.DataViewConfig, params map[string]string) []columnFilter {
+	var filters []columnFilter
+	if conf == nil {
+		for k, v := range params {
+			filters = append(filters, columnFilter{Column: k, Value: v})
+		}
+	} else {
+		for _, label := range conf.Labels {
+			if v, ok := params[label.Name]; ok {
+				filters = append(filters, columnFilter{Column: label.Column, Value: v, Type: label.Type})
+			}
+		}
+	}
+	sort.SliceStable(filters, func(i, j int) bool {
+		return filters[i].Column < filters[j].Column
+	})
+	return filters
+}
+
+// fetchParams returns the params a fetch of the view depends on: those named
+// after a label or label column (pushed down, or matched against file rows)
+// and those referenced by the view's filters, SQL, path and query templates or
+// by its data source's path and header templates. Fetching with them returns
+// the rows a fetch with all params would, so fetches are cached by them alone
+// and runs differing in unrelated params share entries. Without a view config
+// any param may be a column and all are kept.
+func fetchParams(provider config.Provider, conf *config.DataViewConfig, params map[string]string) map[string]string {
+	if conf == nil {
+		return params
+	}
+	used := make(map[string]bool)
+	for _, label := range conf.Labels {
+		used[label.Name] = true
+		used[label.Column] = true
+	}
+	var templates []string
+	for _, filter := range conf.Filters {
+		templates = append(templates, filter.Value)
+	}
+	if conf.Csv != nil {
+		templates = append(templates, conf.Csv.Path)
+	}
+	if conf.Json != nil {
+		templates = append(templates, conf.Json.Path)
+	}
+	if conf.Excel != nil {
+		templates = append(templates, conf.Excel.Path)
+	}
+	if conf.Http != nil {
+		templates = append(templates, conf.Http.Path)
+		for _, tmpl := range conf.Http.Query {
+			templates = append(templates, tmpl)
+		}
+	}
+	if provider != nil && conf.DataSource != "" {
+		if source, err := provider.GetDataSourceConfig(conf.DataSource); err == nil && source != nil {
+			if source.Csv != nil {
+				templates = append(templates, source.Csv.Path)
+			}
+			if source.Http != nil {
+				for _, tmpl := range source.Http.Headers {
+					templates = append(templates, tmpl)
+				}
+			}
+		}
+	}
+	for _, tmpl := range templates {
+		for _, m := range templateVarRe.FindAllStringSubmatch(tmpl, -1) {
+			used[m[1]] = true
+		}
+	}
+	for _, m := range sqlParamRe.FindAllStringSubmatch(conf.Sql, -1) {
+		used[m[1]] = true
+	}
+
+	kept := make(map[string]string)
+	for k, v := range params {
+		if used[k] {
+			kept[k] = v
+		}
+	}
+	return kept
+}
+
+// sqlParamRe finds the :name references bound by bindNamedParams. It also
+// matches inside literals and comments, which only keeps unused params.
+var sqlParamRe = regexp.MustCompile(`(?:^|[^:]):([A-Za-z_][A-Za-z0-9_]*)`)